@@ -0,0 +1,255 @@
+package gocnn
+
+import (
+	"math"
+	"math/rand"
+)
+
+// Transform changes the n-th sample of img in place, drawing the random
+// numbers it needs from r.
+type Transform interface {
+	Apply(img Image, n int, r *rand.Rand)
+}
+
+type TransformFunc func(img Image, n int, r *rand.Rand)
+
+func (f TransformFunc) Apply(img Image, n int, r *rand.Rand) {
+	f(img, n, r)
+}
+
+// Compose applies the transforms in order.
+type Compose []Transform
+
+func (ts Compose) Apply(img Image, n int, r *rand.Rand) {
+	for _, t := range ts {
+		t.Apply(img, n, r)
+	}
+}
+
+// ApplyAll applies t to every sample of img.
+func ApplyAll(t Transform, img Image, r *rand.Rand) {
+	for n := 0; n < img.Shape().N; n++ {
+		t.Apply(img, n, r)
+	}
+}
+
+// RandomApply applies Transform with probability P.
+type RandomApply struct {
+	P         float64
+	Transform Transform
+}
+
+func (t *RandomApply) Apply(img Image, n int, r *rand.Rand) {
+	if r.Float64() < t.P {
+		t.Transform.Apply(img, n, r)
+	}
+}
+
+// Shift moves the sample by up to Max pixels in each direction, filling the
+// uncovered pixels with Fill. It is the random crop of the sample padded by
+// Max pixels.
+type Shift struct {
+	Max  int
+	Fill float64
+}
+
+func (t *Shift) Apply(img Image, n int, r *rand.Rand) {
+	dr := r.Intn(2*t.Max+1) - t.Max
+	dc := r.Intn(2*t.Max+1) - t.Max
+	remap(img, n, t.Fill, func(row, col float64) (float64, float64) {
+		return row - float64(dr), col - float64(dc)
+	})
+}
+
+// Rotate rotates the sample around its centre by an angle drawn uniformly
+// from [-MaxDegrees, MaxDegrees].
+type Rotate struct {
+	MaxDegrees float64
+	Fill       float64
+}
+
+func (t *Rotate) Apply(img Image, n int, r *rand.Rand) {
+	s := img.Shape()
+	theta := (2*r.Float64() - 1) * t.MaxDegrees * math.Pi / 180
+	sin, cos := math.Sincos(theta)
+	cr, cc := float64(s.Row-1)/2, float64(s.Col-1)/2
+	remap(img, n, t.Fill, func(row, col float64) (float64, float64) {
+		y, x := row-cr, col-cc
+		return cr + cos*y - sin*x, cc + sin*y + cos*x
+	})
+}
+
+// HorizontalFlip mirrors the sample left to right with probability P.
+type HorizontalFlip struct {
+	P float64
+}
+
+func (t *HorizontalFlip) Apply(img Image, n int, r *rand.Rand) {
+	if r.Float64() >= t.P {
+		return
+	}
+	s := img.Shape()
+	for ch := 0; ch < s.Ch; ch++ {
+		for row := 0; row < s.Row; row++ {
+			for i, j := 0, s.Col-1; i < j; i, j = i+1, j-1 {
+				a, b := img.Get(n, ch, row, i), img.Get(n, ch, row, j)
+				img.Set(n, ch, row, i, b)
+				img.Set(n, ch, row, j, a)
+			}
+		}
+	}
+}
+
+// Elastic is the elastic distortion of Simard et al.: every pixel is moved
+// by a random displacement in [-1, 1] smoothed by a Gaussian of standard
+// deviation Sigma and scaled by Alpha.
+type Elastic struct {
+	Alpha float64
+	Sigma float64
+	Fill  float64
+}
+
+func (t *Elastic) Apply(img Image, n int, r *rand.Rand) {
+	s := img.Shape()
+	field := func() []float64 {
+		d := make([]float64, s.Row*s.Col)
+		for i := range d {
+			d[i] = 2*r.Float64() - 1
+		}
+		d = gaussianBlur(d, s.Row, s.Col, t.Sigma)
+		for i := range d {
+			d[i] *= t.Alpha
+		}
+		return d
+	}
+	dr, dc := field(), field()
+	remap(img, n, t.Fill, func(row, col float64) (float64, float64) {
+		i := int(row)*s.Col + int(col)
+		return row + dr[i], col + dc[i]
+	})
+}
+
+// GaussianNoise adds a noise of standard deviation Std to every pixel.
+type GaussianNoise struct {
+	Std float64
+}
+
+func (t *GaussianNoise) Apply(img Image, n int, r *rand.Rand) {
+	s := img.Shape()
+	for ch := 0; ch < s.Ch; ch++ {
+		for row := 0; row < s.Row; row++ {
+			for col := 0; col < s.Col; col++ {
+				img.Set(n, ch, row, col, img.Get(n, ch, row, col)+r.NormFloat64()*t.Std)
+			}
+		}
+	}
+}
+
+// RandomErasing fills with Value, with probability P, a rectangle covering
+// a fraction of the sample drawn from [MinArea, MaxArea] with an aspect
+// ratio drawn from [1/MaxAspect, MaxAspect] (Zhong et al.).
+type RandomErasing struct {
+	P         float64
+	MinArea   float64
+	MaxArea   float64
+	MaxAspect float64
+	Value     float64
+}
+
+func NewRandomErasing(p float64) *RandomErasing {
+	return &RandomErasing{P: p, MinArea: 0.02, MaxArea: 0.33, MaxAspect: 3.3}
+}
+
+func (t *RandomErasing) Apply(img Image, n int, r *rand.Rand) {
+	if r.Float64() >= t.P {
+		return
+	}
+	s := img.Shape()
+	area := float64(s.Row*s.Col) * (t.MinArea + r.Float64()*(t.MaxArea-t.MinArea))
+	logAspect := math.Log(t.MaxAspect) * (2*r.Float64() - 1)
+	aspect := math.Exp(logAspect)
+	h := int(math.Sqrt(area*aspect) + 0.5)
+	w := int(math.Sqrt(area/aspect) + 0.5)
+	if h < 1 || w < 1 || h > s.Row || w > s.Col {
+		return
+	}
+	top, left := r.Intn(s.Row-h+1), r.Intn(s.Col-w+1)
+	for ch := 0; ch < s.Ch; ch++ {
+		for row := top; row < top+h; row++ {
+			for col := left; col < left+w; col++ {
+				img.Set(n, ch, row, col, t.Value)
+			}
+		}
+	}
+}
+
+// remap sets every pixel (row, col) of the n-th sample to the value at
+// from(row, col), interpolated bilinearly and taken as fill outside.
+func remap(img Image, n int, fill float64, from func(row, col float64) (float64, float64)) {
+	s := img.Shape()
+	buf := make([]float64, s.Row*s.Col)
+	for ch := 0; ch < s.Ch; ch++ {
+		for row := 0; row < s.Row; row++ {
+			for col := 0; col < s.Col; col++ {
+				sr, sc := from(float64(row), float64(col))
+				buf[row*s.Col+col] = bilinear(img, n, ch, sr, sc, fill)
+			}
+		}
+		for row := 0; row < s.Row; row++ {
+			for col := 0; col < s.Col; col++ {
+				img.Set(n, ch, row, col, buf[row*s.Col+col])
+			}
+		}
+	}
+}
+
+func bilinear(img Image, n, ch int, row, col, fill float64) float64 {
+	s := img.Shape()
+	at := func(r, c int) float64 {
+		if r < 0 || r >= s.Row || c < 0 || c >= s.Col {
+			return fill
+		}
+		return img.Get(n, ch, r, c)
+	}
+	r0, c0 := math.Floor(row), math.Floor(col)
+	fr, fc := row-r0, col-c0
+	r, c := int(r0), int(c0)
+	return at(r, c)*(1-fr)*(1-fc) + at(r, c+1)*(1-fr)*fc +
+		at(r+1, c)*fr*(1-fc) + at(r+1, c+1)*fr*fc
+}
+
+// gaussianBlur smooths a rows x cols field with a separable Gaussian kernel.
+func gaussianBlur(d []float64, rows, cols int, sigma float64) []float64 {
+	if sigma <= 0 {
+		return d
+	}
+	radius := int(math.Ceil(3 * sigma))
+	kernel := make([]float64, 2*radius+1)
+	sum := 0.0
+	for i := range kernel {
+		x := float64(i - radius)
+		kernel[i] = math.Exp(-x * x / (2 * sigma * sigma))
+		sum += kernel[i]
+	}
+	for i := range kernel {
+		kernel[i] /= sum
+	}
+	conv := func(src []float64, n, m int, at func(i, j int) int) []float64 {
+		dst := make([]float64, len(src))
+		for i := 0; i < n; i++ {
+			for j := 0; j < m; j++ {
+				v := 0.0
+				for k, w := range kernel {
+					jj := j + k - radius
+					if jj >= 0 && jj < m {
+						v += w * src[at(i, jj)]
+					}
+				}
+				dst[at(i, j)] = v
+			}
+		}
+		return dst
+	}
+	d = conv(d, rows, cols, func(r, c int) int { return r*cols + c })
+	return conv(d, cols, rows, func(c, r int) int { return r*cols + c })
+}
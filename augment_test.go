@@ -0,0 +1,148 @@
+package gocnn
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+)
+
+// dot is a batch of 2 images of 7x7 with a 1 at (2, 4) of the first one.
+func dot() *ArrayImage {
+	img := NewEmptyStrage(NewShape(2, 1, 7, 7))
+	img.Set(0, 0, 2, 4, 1)
+	return img
+}
+
+func ones(img Image, n int) [][2]int {
+	var ret [][2]int
+	s := img.Shape()
+	for r := 0; r < s.Row; r++ {
+		for c := 0; c < s.Col; c++ {
+			if math.Abs(img.Get(n, 0, r, c)-1) < 1e-9 {
+				ret = append(ret, [2]int{r, c})
+			}
+		}
+	}
+	return ret
+}
+
+func TestTransformIdentity(t *testing.T) {
+	cases := []struct {
+		title     string
+		transform Transform
+	}{
+		{title: "shift 0", transform: &Shift{Max: 0}},
+		{title: "rotate 0", transform: &Rotate{MaxDegrees: 0}},
+		{title: "flip twice", transform: Compose{&HorizontalFlip{P: 1}, &HorizontalFlip{P: 1}}},
+		{title: "no flip", transform: &HorizontalFlip{P: 0}},
+		{title: "elastic 0", transform: &Elastic{Alpha: 0, Sigma: 2}},
+		{title: "noise 0", transform: &GaussianNoise{Std: 0}},
+		{title: "no erasing", transform: &RandomErasing{P: 0}},
+		{title: "never", transform: &RandomApply{P: 0, Transform: &HorizontalFlip{P: 1}}},
+	}
+	for _, c := range cases {
+		img := dot()
+		ApplyAll(c.transform, img, rand.New(rand.NewSource(1)))
+		if !img.Equal(dot()) {
+			t.Fatalf("%s expect %v but got %v", c.title, dot(), img)
+		}
+	}
+}
+
+func TestTransform(t *testing.T) {
+	cases := []struct {
+		title string
+		t     Transform
+		check func(img Image) bool
+	}{
+		{
+			title: "flip",
+			t:     &HorizontalFlip{P: 1},
+			check: func(img Image) bool {
+				p := ones(img, 0)
+				return len(p) == 1 && p[0] == [2]int{2, 2}
+			},
+		},
+		{
+			title: "shift",
+			t:     &Shift{Max: 1},
+			check: func(img Image) bool {
+				p := ones(img, 0)
+				return len(p) == 1 && math.Abs(float64(p[0][0]-2)) <= 1 && math.Abs(float64(p[0][1]-4)) <= 1
+			},
+		},
+		{
+			title: "rotate keeps the centre",
+			t: Compose{
+				TransformFunc(func(img Image, n int, r *rand.Rand) { img.Set(n, 0, 3, 3, 1) }),
+				&Rotate{MaxDegrees: 30},
+			},
+			check: func(img Image) bool {
+				return math.Abs(img.Get(0, 0, 3, 3)-1) < 1e-9
+			},
+		},
+		{
+			title: "erasing",
+			t:     &RandomErasing{P: 1, MinArea: 0.2, MaxArea: 0.2, MaxAspect: 1, Value: 1},
+			check: func(img Image) bool {
+				// a square of 3x3 covering about 20% of 7x7, maybe on the dot
+				n := len(ones(img, 0))
+				return n == 9 || n == 10
+			},
+		},
+		{
+			title: "other samples untouched",
+			t:     &GaussianNoise{Std: 1},
+			check: func(img Image) bool {
+				return len(ones(img, 1)) == 0
+			},
+		},
+	}
+	for _, c := range cases {
+		for seed := int64(0); seed < 10; seed++ {
+			img := dot()
+			c.t.Apply(img, 0, rand.New(rand.NewSource(seed)))
+			if !c.check(img) {
+				t.Fatalf("%s (seed %d) got %v", c.title, seed, img)
+			}
+		}
+	}
+}
+
+func TestTransformSeed(t *testing.T) {
+	aug := Compose{
+		&Shift{Max: 2},
+		&Rotate{MaxDegrees: 15},
+		&Elastic{Alpha: 2, Sigma: 1},
+		&GaussianNoise{Std: 0.1},
+		NewRandomErasing(0.5),
+	}
+	a, b := dot(), dot()
+	ApplyAll(aug, a, rand.New(rand.NewSource(3)))
+	ApplyAll(aug, b, rand.New(rand.NewSource(3)))
+	if !a.Equal(b) {
+		t.Fatalf("same seed should give the same images")
+	}
+	ApplyAll(aug, b, rand.New(rand.NewSource(3)))
+	if a.Equal(b) {
+		t.Fatalf("should change the images")
+	}
+}
+
+func TestGaussianNoise(t *testing.T) {
+	img := NewEmptyStrage(NewShape(1, 1, 100, 100))
+	(&GaussianNoise{Std: 0.5}).Apply(img, 0, rand.New(rand.NewSource(1)))
+	sum, sq := 0.0, 0.0
+	for r := 0; r < 100; r++ {
+		for c := 0; c < 100; c++ {
+			x := img.Get(0, 0, r, c)
+			sum += x
+			sq += x * x
+		}
+	}
+	mean := sum / 10000
+	std := math.Sqrt(sq/10000 - mean*mean)
+	if math.Abs(mean) > 0.02 || math.Abs(std-0.5) > 0.02 {
+		t.Fatalf("expect N(0, 0.5) but got N(%v, %v)", mean, std)
+	}
+}
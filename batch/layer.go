@@ -5,6 +5,7 @@ import (
 	mat "github.com/gonum/matrix/mat64"
 
 	"github.com/ajiyoshi/gocnn/matrix"
+	"github.com/ajiyoshi/gocnn/nd"
 	"github.com/ajiyoshi/gocnn/optimizer"
 )
 
@@ -23,6 +24,9 @@ var (
 	_ Layer     = &AffineLayer{}
 	_ Layer     = &ReLULayer{}
 	_ LastLayer = &SoftMaxWithLoss{}
+	_ LastLayer = &MeanSquaredLoss{}
+
+	_ optimizer.Parameterized = &AffineLayer{}
 )
 
 type AffineLayer struct {
@@ -101,6 +105,13 @@ func (l *AffineLayer) Update() {
 	l.optimizer.UpdateBias(l.Bias, l.DBias)
 }
 
+func (l *AffineLayer) Params() []optimizer.Param {
+	return []optimizer.Param{
+		{Name: "weight", Value: nd.NewDenseArray(l.Weight), Grad: nd.NewDenseArray(l.DWeight)},
+		{Name: "bias", Value: nd.NewVectorArray(l.Bias), Grad: nd.NewVectorArray(l.DBias)},
+	}
+}
+
 type ReLULayer struct {
 	mask *mat.Dense
 }
@@ -154,3 +165,30 @@ func (l *SoftMaxWithLoss) Backward(dout float64) mat.Matrix {
 	dx.Scale(1.0/float64(r), &dx)
 	return &dx
 }
+
+// MeanSquaredLoss is half the squared error between the output and the
+// targets, averaged over the batch, for a regression.
+type MeanSquaredLoss struct {
+	diff *mat.Dense
+}
+
+func NewMeanSquaredLoss() *MeanSquaredLoss {
+	return &MeanSquaredLoss{}
+}
+
+func (l *MeanSquaredLoss) Forward(x, t mat.Matrix) float64 {
+	r, _ := x.Dims()
+	var diff mat.Dense
+	diff.Sub(x, t)
+	l.diff = &diff
+	var sq mat.Dense
+	sq.MulElem(l.diff, l.diff)
+	return mat.Sum(&sq) / float64(2*r)
+}
+
+func (l *MeanSquaredLoss) Backward(dout float64) mat.Matrix {
+	r, _ := l.diff.Dims()
+	var dx mat.Dense
+	dx.Scale(dout/float64(r), l.diff)
+	return &dx
+}
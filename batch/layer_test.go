@@ -153,3 +153,16 @@ func TestReLULayer(t *testing.T) {
 		}
 	}
 }
+
+func TestMeanSquaredLoss(t *testing.T) {
+	l := NewMeanSquaredLoss()
+	x := mat64.NewDense(2, 1, []float64{1, 4})
+	tt := mat64.NewDense(2, 1, []float64{2, 2})
+	if loss := l.Forward(x, tt); loss != 1.25 {
+		t.Fatalf("expect 1.25 but got %v", loss)
+	}
+	expect := mat64.NewDense(2, 1, []float64{-0.5, 1})
+	if dx := l.Backward(1); !mat64.Equal(dx, expect) {
+		t.Fatalf("expect %v but got %v", mat64.Formatted(expect), mat64.Formatted(dx))
+	}
+}
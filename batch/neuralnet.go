@@ -1,9 +1,12 @@
 package batch
 
 import (
+	"strconv"
+
 	"github.com/gonum/matrix/mat64"
 
 	"github.com/ajiyoshi/gocnn/matrix"
+	"github.com/ajiyoshi/gocnn/optimizer"
 )
 
 type NeuralNetLayers interface {
@@ -12,11 +15,32 @@ type NeuralNetLayers interface {
 }
 
 type NeuralNet struct {
-	layers NeuralNetLayers
+	layers    NeuralNetLayers
+	optimizer *optimizer.NetworkOptimizer
 }
 
+var _ optimizer.Parameterized = (*NeuralNet)(nil)
+
 func NewNeuralNet(ls NeuralNetLayers) *NeuralNet {
-	return &NeuralNet{ls}
+	return &NeuralNet{layers: ls}
+}
+
+// SetOptimizer makes Update step all parameters with o at once instead of
+// calling the optimizer of each layer.
+func (nn *NeuralNet) SetOptimizer(o *optimizer.NetworkOptimizer) {
+	nn.optimizer = o
+}
+
+// Params are the parameters of the layers, named after the index of the
+// layer like "0.weight".
+func (nn *NeuralNet) Params() []optimizer.Param {
+	var ret []optimizer.Param
+	for i, layer := range nn.Layers() {
+		if p, ok := layer.(optimizer.Parameterized); ok {
+			ret = append(ret, optimizer.Prefix(strconv.Itoa(i), p.Params())...)
+		}
+	}
+	return ret
 }
 
 func (nn *NeuralNet) Layers() []Layer {
@@ -58,6 +82,10 @@ func (nn *NeuralNet) BackProp() mat64.Matrix {
 }
 
 func (nn *NeuralNet) Update() {
+	if nn.optimizer != nil {
+		nn.optimizer.Step(nn.Params())
+		return
+	}
 	for _, layer := range nn.Layers() {
 		layer.Update()
 	}
@@ -82,3 +82,34 @@ func TestBatchNNSimple(t *testing.T) {
 		}
 	}
 }
+
+func TestNetworkOptimizer(t *testing.T) {
+	w := mat64.NewDense(2, 3, []float64{
+		0.47355232, 0.9977393, 0.84668094,
+		0.85557411, 0.0356366, 0.69422093,
+	})
+	x := mat64.NewDense(1, 2, []float64{0.6, 0.9})
+	tt := mat64.NewDense(1, 3, []float64{0, 0, 1})
+
+	// updated by the optimizer of the layer
+	layers := NewBatchNNSimple(mat64.DenseCopyOf(w))
+	NewNeuralNet(layers).Train(x, tt)
+
+	// updated by one optimizer for the whole network
+	layers2 := NewBatchNNSimple(mat64.DenseCopyOf(w))
+	nn := NewNeuralNet(layers2)
+	nn.SetOptimizer(optimizer.NewNetworkOptimizer(optimizer.NewMomentumFactory(0.1, 0.1)))
+
+	ps := nn.Params()
+	if len(ps) != 2 || ps[0].Name != "0.weight" || ps[1].Name != "0.bias" {
+		t.Fatalf("unexpected params %v", ps)
+	}
+	nn.Train(x, tt)
+
+	if !mat64.EqualApprox(layers.affine.Weight, layers2.affine.Weight, 1e-9) {
+		t.Fatalf("expect %v but got %v", layers.affine.Weight, layers2.affine.Weight)
+	}
+	if !mat64.EqualApprox(layers.affine.Bias, layers2.affine.Bias, 1e-9) {
+		t.Fatalf("expect %v but got %v", layers.affine.Bias, layers2.affine.Bias)
+	}
+}
@@ -0,0 +1,237 @@
+package batch
+
+import (
+	"fmt"
+	"math"
+
+	mat "github.com/gonum/matrix/mat64"
+)
+
+var _ Layer = (*Normalize)(nil)
+
+// Normalize is a fixed preprocessing layer fitted on the training set with
+// Stats. It maps x to (x - Mean) * Scale feature by feature or, when Whiten
+// is set, to (x - Mean) Whiten. Being a layer of the network, it is applied
+// in the same way when training and predicting. It has no parameters to
+// learn.
+type Normalize struct {
+	Mean   *mat.Vector
+	Scale  *mat.Vector
+	Whiten *mat.Dense
+}
+
+func (l *Normalize) Forward(x mat.Matrix) mat.Matrix {
+	_, c := x.Dims()
+	if c != l.Mean.Len() {
+		panic(fmt.Sprintf("expect %d but got %d", l.Mean.Len(), c))
+	}
+	var ret mat.Dense
+	ret.Apply(func(i, j int, v float64) float64 {
+		return v - l.Mean.At(j, 0)
+	}, x)
+	if l.Whiten != nil {
+		var w mat.Dense
+		w.Mul(&ret, l.Whiten)
+		return &w
+	}
+	ret.Apply(func(i, j int, v float64) float64 {
+		return v * l.Scale.At(j, 0)
+	}, &ret)
+	return &ret
+}
+func (l *Normalize) Backward(dout mat.Matrix) mat.Matrix {
+	var ret mat.Dense
+	if l.Whiten != nil {
+		ret.Mul(dout, l.Whiten.T())
+		return &ret
+	}
+	ret.Apply(func(i, j int, v float64) float64 {
+		return v * l.Scale.At(j, 0)
+	}, dout)
+	return &ret
+}
+func (l *Normalize) Update() {
+}
+
+// Whitening is the way Stats.Whiten decorrelates the features.
+type Whitening int
+
+const (
+	// PCA rotates the features onto the principal axes.
+	PCA Whitening = iota
+	// ZCA rotates them back afterwards, so that the output stays close to
+	// the input.
+	ZCA
+)
+
+// Stats accumulates the mean and the variance of each feature over the
+// batches given to Add, and their covariance when made with cov.
+type Stats struct {
+	n     int
+	sum   []float64
+	sumSq []float64
+	cross *mat.Dense
+}
+
+func NewStats(features int, cov bool) *Stats {
+	s := &Stats{
+		sum:   make([]float64, features),
+		sumSq: make([]float64, features),
+	}
+	if cov {
+		s.cross = mat.NewDense(features, features, nil)
+	}
+	return s
+}
+
+func (s *Stats) Add(x mat.Matrix) {
+	r, c := x.Dims()
+	if c != len(s.sum) {
+		panic(fmt.Sprintf("expect %d but got %d", len(s.sum), c))
+	}
+	for i := 0; i < r; i++ {
+		for j := 0; j < c; j++ {
+			v := x.At(i, j)
+			s.sum[j] += v
+			s.sumSq[j] += v * v
+		}
+	}
+	if s.cross != nil {
+		var xx mat.Dense
+		xx.Mul(x.T(), x)
+		s.cross.Add(s.cross, &xx)
+	}
+	s.n += r
+}
+
+// Len is the number of examples added.
+func (s *Stats) Len() int {
+	return s.n
+}
+
+func (s *Stats) Mean() *mat.Vector {
+	s.check()
+	ret := mat.NewVector(len(s.sum), nil)
+	for j, v := range s.sum {
+		ret.SetVec(j, v/float64(s.n))
+	}
+	return ret
+}
+
+// Var is the population variance of each feature.
+func (s *Stats) Var() *mat.Vector {
+	mean := s.Mean()
+	ret := mat.NewVector(len(s.sum), nil)
+	for j, v := range s.sumSq {
+		m := mean.At(j, 0)
+		ret.SetVec(j, math.Max(v/float64(s.n)-m*m, 0))
+	}
+	return ret
+}
+
+// Standardize makes every feature of zero mean and unit variance. eps is
+// added to the variance so that constant features, like the borders of
+// MNIST digits, stay finite.
+func (s *Stats) Standardize(eps float64) *Normalize {
+	variance := s.Var()
+	scale := mat.NewVector(variance.Len(), nil)
+	for j := 0; j < variance.Len(); j++ {
+		scale.SetVec(j, 1/math.Sqrt(variance.At(j, 0)+eps))
+	}
+	return &Normalize{Mean: s.Mean(), Scale: scale}
+}
+
+// PerChannel makes every channel of zero mean and unit variance, taking the
+// features as channels images of the same size one after another.
+func (s *Stats) PerChannel(channels int, eps float64) *Normalize {
+	s.check()
+	size := len(s.sum)
+	if channels <= 0 || size%channels != 0 {
+		panic(fmt.Sprintf("%d features cannot be split into %d channels", size, channels))
+	}
+	per := size / channels
+	mean := mat.NewVector(size, nil)
+	scale := mat.NewVector(size, nil)
+	count := float64(s.n * per)
+	for ch := 0; ch < channels; ch++ {
+		sum, sumSq := 0.0, 0.0
+		for j := ch * per; j < (ch+1)*per; j++ {
+			sum += s.sum[j]
+			sumSq += s.sumSq[j]
+		}
+		m := sum / count
+		sc := 1 / math.Sqrt(math.Max(sumSq/count-m*m, 0)+eps)
+		for j := ch * per; j < (ch+1)*per; j++ {
+			mean.SetVec(j, m)
+			scale.SetVec(j, sc)
+		}
+	}
+	return &Normalize{Mean: mean, Scale: scale}
+}
+
+// Whiten decorrelates the features and makes them of unit variance. eps is
+// added to the eigenvalues of the covariance to keep the directions of
+// little variance from blowing up. The Stats should be made with cov.
+func (s *Stats) Whiten(w Whitening, eps float64) *Normalize {
+	var eigen mat.EigenSym
+	if !eigen.Factorize(s.Cov(), true) {
+		panic("eigen decomposition of the covariance failed")
+	}
+	values := eigen.Values(nil)
+	vectors := &mat.Dense{}
+	vectors.EigenvectorsSym(&eigen)
+	n := len(values)
+
+	// u : vectors scaled column by column by 1/sqrt(λ+eps)
+	u := mat.NewDense(n, n, nil)
+	u.Apply(func(i, j int, v float64) float64 {
+		return v / math.Sqrt(math.Max(values[j], 0)+eps)
+	}, vectors)
+	if w == ZCA {
+		var zca mat.Dense
+		zca.Mul(u, vectors.T())
+		u = &zca
+	}
+	return &Normalize{Mean: s.Mean(), Whiten: u}
+}
+
+// Cov is the population covariance of the features.
+func (s *Stats) Cov() *mat.SymDense {
+	if s.cross == nil {
+		panic("stats should be made with cov")
+	}
+	mean := s.Mean()
+	n := len(s.sum)
+	ret := mat.NewSymDense(n, nil)
+	for i := 0; i < n; i++ {
+		for j := i; j < n; j++ {
+			ret.SetSym(i, j, s.cross.At(i, j)/float64(s.n)-mean.At(i, 0)*mean.At(j, 0))
+		}
+	}
+	return ret
+}
+
+func (s *Stats) check() {
+	if s.n == 0 {
+		panic("no example was added")
+	}
+}
+
+// NormalizeSpec puts Normalize in a Sequential.
+type NormalizeSpec struct {
+	Normalize *Normalize
+}
+
+func Normalization(n *Normalize) *NormalizeSpec {
+	return &NormalizeSpec{Normalize: n}
+}
+
+func (s *NormalizeSpec) OutputSize(input int) int {
+	return input
+}
+func (s *NormalizeSpec) Build(input int, seq *Sequential) Layer {
+	if s.Normalize.Mean.Len() != input {
+		panic(fmt.Sprintf("expect %d features but got %d", input, s.Normalize.Mean.Len()))
+	}
+	return s.Normalize
+}
@@ -0,0 +1,125 @@
+package batch
+
+import (
+	"math"
+	"testing"
+
+	"github.com/gonum/matrix/mat64"
+)
+
+func normalizeData() *mat64.Dense {
+	return mat64.NewDense(4, 3, []float64{
+		1, 2, 5,
+		3, 2, 1,
+		5, 2, 4,
+		7, 2, 2,
+	})
+}
+
+func TestStandardize(t *testing.T) {
+	s := NewStats(3, false)
+	x := normalizeData()
+	s.Add(x.View(0, 0, 1, 3))
+	s.Add(x.View(1, 0, 3, 3))
+	if s.Len() != 4 {
+		t.Fatalf("expect 4 but got %d", s.Len())
+	}
+	mean := mat64.NewVector(3, []float64{4, 2, 3})
+	if !mat64.EqualApprox(s.Mean(), mean, 1e-12) {
+		t.Fatalf("expect %v but got %v", mat64.Formatted(mean), mat64.Formatted(s.Mean()))
+	}
+
+	l := s.Standardize(1e-8)
+	y := l.Forward(x)
+	for j, expect := range []float64{1, 0, 1} {
+		col := mat64.Col(nil, j, y)
+		m, v := meanVar(col)
+		if math.Abs(m) > 1e-9 || math.Abs(v-expect) > 1e-6 {
+			t.Fatalf("feature %d expect (0, %v) but got (%v, %v)", j, expect, m, v)
+		}
+	}
+
+	dout := mat64.NewDense(1, 3, []float64{1, 1, 1})
+	dx := l.Backward(dout)
+	for j := 0; j < 3; j++ {
+		if dx.At(0, j) != l.Scale.At(j, 0) {
+			t.Fatalf("expect %v but got %v", l.Scale.At(j, 0), dx.At(0, j))
+		}
+	}
+}
+
+func TestPerChannel(t *testing.T) {
+	// 2 channels of 2 features
+	x := mat64.NewDense(2, 4, []float64{
+		0, 2, 10, 10,
+		2, 4, 30, 30,
+	})
+	s := NewStats(4, false)
+	s.Add(x)
+	l := s.PerChannel(2, 0)
+	mean := mat64.NewVector(4, []float64{2, 2, 20, 20})
+	scale := mat64.NewVector(4, []float64{1 / math.Sqrt(2), 1 / math.Sqrt(2), 0.1, 0.1})
+	if !mat64.EqualApprox(l.Mean, mean, 1e-12) {
+		t.Fatalf("expect %v but got %v", mat64.Formatted(mean), mat64.Formatted(l.Mean))
+	}
+	if !mat64.EqualApprox(l.Scale, scale, 1e-12) {
+		t.Fatalf("expect %v but got %v", mat64.Formatted(scale), mat64.Formatted(l.Scale))
+	}
+}
+
+func TestWhiten(t *testing.T) {
+	x := mat64.NewDense(6, 2, []float64{
+		1, 2,
+		2, 3,
+		3, 5,
+		4, 4,
+		5, 7,
+		6, 6,
+	})
+	cases := []struct {
+		title string
+		w     Whitening
+	}{
+		{title: "pca", w: PCA},
+		{title: "zca", w: ZCA},
+	}
+	for _, c := range cases {
+		s := NewStats(2, true)
+		s.Add(x)
+		l := s.Whiten(c.w, 0)
+		y := l.Forward(x)
+
+		// the covariance of the output is the identity
+		out := NewStats(2, true)
+		out.Add(y)
+		cov := out.Cov()
+		id := mat64.NewDense(2, 2, []float64{1, 0, 0, 1})
+		if !mat64.EqualApprox(cov, id, 1e-9) {
+			t.Fatalf("%s expect %v but got %v", c.title, mat64.Formatted(id), mat64.Formatted(cov))
+		}
+	}
+}
+
+func TestNormalizeSpec(t *testing.T) {
+	s := NewStats(3, false)
+	s.Add(normalizeData())
+	n := s.Standardize(1e-8)
+	nn := NewSequential(3, nil).Add(Normalization(n)).Add(ReLU()).Build()
+	if nn.Layers()[0] != n {
+		t.Fatalf("expect %v but got %v", n, nn.Layers()[0])
+	}
+	if len(nn.Params()) != 0 {
+		t.Fatalf("expect no params but got %d", len(nn.Params()))
+	}
+}
+
+func meanVar(xs []float64) (mean, variance float64) {
+	for _, x := range xs {
+		mean += x
+	}
+	mean /= float64(len(xs))
+	for _, x := range xs {
+		variance += (x - mean) * (x - mean)
+	}
+	return mean, variance / float64(len(xs))
+}
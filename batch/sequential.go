@@ -0,0 +1,137 @@
+package batch
+
+import (
+	"fmt"
+
+	"github.com/ajiyoshi/gocnn/optimizer"
+)
+
+// LayerSpec describes a layer without its input size, which is given by the
+// output size of the previous layer when the network is built. Build takes
+// the settings shared by all layers, such as the optimizer, from s.
+type LayerSpec interface {
+	OutputSize(input int) int
+	Build(input int, s *Sequential) Layer
+}
+
+// AffineSpec is an AffineLayer with Output units. Weights are initialised
+// with the standard deviation Std, or with the one of the Sequential if 0.
+type AffineSpec struct {
+	Output int
+	Std    float64
+}
+
+func Affine(output int) *AffineSpec {
+	return &AffineSpec{Output: output}
+}
+
+func (s *AffineSpec) OutputSize(input int) int {
+	return s.Output
+}
+func (s *AffineSpec) Build(input int, seq *Sequential) Layer {
+	if s.Output <= 0 {
+		panic(fmt.Sprintf("output size should be positive but got %d", s.Output))
+	}
+	std := s.Std
+	if std == 0 {
+		std = seq.WeightInitStd
+	}
+	return NewAffine(std, input, s.Output, seq.NewOptimizer())
+}
+
+type ReLUSpec struct{}
+
+func ReLU() *ReLUSpec {
+	return &ReLUSpec{}
+}
+
+func (s *ReLUSpec) OutputSize(input int) int {
+	return input
+}
+func (s *ReLUSpec) Build(input int, seq *Sequential) Layer {
+	return NewReLU()
+}
+
+// Sequential builds a NeuralNet from a stack of LayerSpec:
+//
+//	nn := NewSequential(784, f).Add(Affine(100)).Add(ReLU()).Add(Affine(10)).Build()
+//
+// Each layer gets its own optimizer from f. With a nil f the layers have no
+// optimizer and the network should be given one by NeuralNet.SetOptimizer.
+type Sequential struct {
+	Input         int
+	Optimizer     optimizer.OptimizerFactory
+	WeightInitStd float64
+	Loss          func() LastLayer
+
+	specs []LayerSpec
+}
+
+func NewSequential(input int, f optimizer.OptimizerFactory) *Sequential {
+	return &Sequential{
+		Input:         input,
+		Optimizer:     f,
+		WeightInitStd: WeightInitStd,
+		Loss: func() LastLayer {
+			return NewSoftMaxWithLoss()
+		},
+	}
+}
+
+func (s *Sequential) Add(spec LayerSpec) *Sequential {
+	s.specs = append(s.specs, spec)
+	return s
+}
+
+// InitStd sets the default standard deviation of the initial weights.
+func (s *Sequential) InitStd(std float64) *Sequential {
+	s.WeightInitStd = std
+	return s
+}
+
+// NewOptimizer is an optimizer for a new layer, nil without Optimizer.
+func (s *Sequential) NewOptimizer() optimizer.Optimizer {
+	if s.Optimizer == nil {
+		return nil
+	}
+	return s.Optimizer()
+}
+
+// OutputSize is the size of the output of the last layer added so far.
+func (s *Sequential) OutputSize() int {
+	size := s.Input
+	for _, spec := range s.specs {
+		size = spec.OutputSize(size)
+	}
+	return size
+}
+
+func (s *Sequential) Build() *NeuralNet {
+	return NewNeuralNet(s.Layers())
+}
+
+// Layers builds new layers from the specs.
+func (s *Sequential) Layers() *SequentialLayers {
+	ret := &SequentialLayers{last: s.Loss()}
+	size := s.Input
+	for _, spec := range s.specs {
+		ret.layers = append(ret.layers, spec.Build(size, s))
+		size = spec.OutputSize(size)
+	}
+	return ret
+}
+
+// SequentialLayers are the layers built by Sequential.
+type SequentialLayers struct {
+	layers []Layer
+	last   LastLayer
+}
+
+var _ NeuralNetLayers = (*SequentialLayers)(nil)
+
+func (ls *SequentialLayers) Layers() []Layer {
+	return ls.layers
+}
+func (ls *SequentialLayers) Last() LastLayer {
+	return ls.last
+}
@@ -0,0 +1,50 @@
+package batch
+
+import (
+	"testing"
+
+	"github.com/gonum/matrix/mat64"
+
+	"github.com/ajiyoshi/gocnn/optimizer"
+)
+
+func TestSequential(t *testing.T) {
+	s := NewSequential(4, optimizer.NewSGDFactory(0.1)).
+		InitStd(0.1).
+		Add(Affine(5)).Add(ReLU()).
+		Add(Affine(3)).Add(ReLU()).
+		Add(&AffineSpec{Output: 2, Std: 1})
+	if s.OutputSize() != 2 {
+		t.Fatalf("expect 2 but got %d", s.OutputSize())
+	}
+
+	nn := s.Build()
+	ls := nn.Layers()
+	if len(ls) != 5 {
+		t.Fatalf("expect 5 layers but got %d", len(ls))
+	}
+	for i, c := range []struct {
+		in, out int
+	}{{4, 5}, {5, 3}, {3, 2}} {
+		r, col := ls[i*2].(*AffineLayer).Weight.Dims()
+		if r != c.in || col != c.out {
+			t.Fatalf("layer %d expect (%d, %d) but got (%d, %d)", i*2, c.in, c.out, r, col)
+		}
+	}
+
+	x := mat64.NewDense(2, 4, []float64{
+		1, 2, 3, 4,
+		4, 3, 2, 1,
+	})
+	tt := mat64.NewDense(2, 2, []float64{
+		1, 0,
+		0, 1,
+	})
+	before := nn.Loss(x, tt)
+	for i := 0; i < 100; i++ {
+		nn.Train(x, tt)
+	}
+	if after := nn.Loss(x, tt); after >= before {
+		t.Fatalf("loss should decrease but got %v -> %v", before, after)
+	}
+}
@@ -0,0 +1,22 @@
+package batch
+
+import (
+	"fmt"
+
+	"github.com/gonum/matrix/mat64"
+)
+
+// FromVector views a single sample as a batch of one row, which every Layer
+// accepts as is.
+func FromVector(v *mat64.Vector) mat64.Matrix {
+	return v.T()
+}
+
+// ToVector copies the only row of a batch of one sample into a vector.
+func ToVector(m mat64.Matrix) *mat64.Vector {
+	r, c := m.Dims()
+	if r != 1 {
+		panic(fmt.Sprintf("expect 1 row but got %d", r))
+	}
+	return mat64.NewVector(c, mat64.Row(nil, 0, m))
+}
@@ -0,0 +1,206 @@
+package gocnn
+
+import (
+	"fmt"
+
+	mat "github.com/gonum/matrix/mat64"
+
+	"github.com/ajiyoshi/gocnn/batch"
+	"github.com/ajiyoshi/gocnn/optimizer"
+)
+
+// ImageLayerSpec describes an ImageLayer without its input shape, which is
+// given by the output shape of the previous layer when the network is built.
+type ImageLayerSpec interface {
+	OutputShape(in *Shape) *Shape
+	Build(in *Shape, b *CNNBuilder) ImageLayer
+}
+
+// ConvSpec is a Convolution of Filters filters of Row x Col. Weights are
+// initialised with the standard deviation Std, or with the one of the
+// CNNBuilder if 0.
+type ConvSpec struct {
+	Filters int
+	Row     int
+	Col     int
+	Stride  int
+	Pad     int
+	Std     float64
+}
+
+func (s *ConvSpec) OutputShape(in *Shape) *Shape {
+	checkStride(s.Stride)
+	return &Shape{
+		N:   in.N,
+		Ch:  s.Filters,
+		Row: outSize(in.Row, s.Row, s.Stride, s.Pad),
+		Col: outSize(in.Col, s.Col, s.Stride, s.Pad),
+	}
+}
+func (s *ConvSpec) Build(in *Shape, b *CNNBuilder) ImageLayer {
+	std := s.Std
+	if std == 0 {
+		std = b.WeightInitStd
+	}
+	return &Convolution{
+		Weight:    NewRandomImage(NewShape(s.Filters, in.Ch, s.Row, s.Col), std),
+		Bias:      mat.NewVector(s.Filters, nil),
+		Stride:    s.Stride,
+		Pad:       s.Pad,
+		Optimizer: b.newOptimizer(),
+	}
+}
+
+// PoolSpec is a max Pooling of Row x Col.
+type PoolSpec struct {
+	Row    int
+	Col    int
+	Stride int
+	Pad    int
+}
+
+func (s *PoolSpec) layer() *Pooling {
+	return &Pooling{Row: s.Row, Col: s.Col, Stride: s.Stride, Pad: s.Pad}
+}
+func (s *PoolSpec) OutputShape(in *Shape) *Shape {
+	checkStride(s.Stride)
+	return s.layer().OutputShape(in)
+}
+func (s *PoolSpec) Build(in *Shape, b *CNNBuilder) ImageLayer {
+	return s.layer()
+}
+
+type ReLUSpec struct{}
+
+func (s *ReLUSpec) OutputShape(in *Shape) *Shape {
+	return in
+}
+func (s *ReLUSpec) Build(in *Shape, b *CNNBuilder) ImageLayer {
+	return &ReLU{}
+}
+
+// NormalizeSpec is a Normalize fitted on images of the input shape.
+type NormalizeSpec struct {
+	Normalize *batch.Normalize
+}
+
+func (s *NormalizeSpec) OutputShape(in *Shape) *Shape {
+	return in
+}
+func (s *NormalizeSpec) Build(in *Shape, b *CNNBuilder) ImageLayer {
+	if size := in.Ch * in.Row * in.Col; s.Normalize.Mean.Len() != size {
+		panic(fmt.Sprintf("expect %d features but got %d", size, s.Normalize.Mean.Len()))
+	}
+	return &Normalize{Layer: s.Normalize}
+}
+
+// CNNBuilder builds a SimpleCNN from a chain of image layers followed by a
+// dense head, inferring the shape of each layer from the previous one:
+//
+//	cnn := NewCNNBuilder(shape, f).
+//		Conv(30, 5, 1, 0).ReLU().Pool(2, 2).
+//		Dense(batch.Affine(100), batch.ReLU(), batch.Affine(10)).
+//		Build()
+//
+// Each layer gets its own optimizer from f. With a nil f the layers have no
+// optimizer and the network should be given one by SimpleCNN.SetOptimizer.
+type CNNBuilder struct {
+	Input         *Shape
+	Optimizer     optimizer.OptimizerFactory
+	WeightInitStd float64
+
+	specs []ImageLayerSpec
+	head  []batch.LayerSpec
+}
+
+func NewCNNBuilder(s *Shape, f optimizer.OptimizerFactory) *CNNBuilder {
+	return &CNNBuilder{
+		Input:         s,
+		Optimizer:     f,
+		WeightInitStd: WeightInitStd,
+	}
+}
+
+func (b *CNNBuilder) Add(spec ImageLayerSpec) *CNNBuilder {
+	b.specs = append(b.specs, spec)
+	return b
+}
+
+// Conv adds a Convolution of filters square filters of size x size.
+func (b *CNNBuilder) Conv(filters, size, stride, pad int) *CNNBuilder {
+	return b.Add(&ConvSpec{Filters: filters, Row: size, Col: size, Stride: stride, Pad: pad})
+}
+
+// Pool adds a max Pooling of size x size.
+func (b *CNNBuilder) Pool(size, stride int) *CNNBuilder {
+	return b.Add(&PoolSpec{Row: size, Col: size, Stride: stride})
+}
+
+func (b *CNNBuilder) ReLU() *CNNBuilder {
+	return b.Add(&ReLUSpec{})
+}
+
+// Normalize adds a Normalize with n, usually fitted by batch.Stats on the
+// training images before the first layer.
+func (b *CNNBuilder) Normalize(n *batch.Normalize) *CNNBuilder {
+	return b.Add(&NormalizeSpec{Normalize: n})
+}
+
+// Dense adds layers to the head fed with the flattened output of the image
+// layers.
+func (b *CNNBuilder) Dense(specs ...batch.LayerSpec) *CNNBuilder {
+	b.head = append(b.head, specs...)
+	return b
+}
+
+// InitStd sets the default standard deviation of the initial weights.
+func (b *CNNBuilder) InitStd(std float64) *CNNBuilder {
+	b.WeightInitStd = std
+	return b
+}
+
+// OutputShape is the shape of the output of the image layers.
+func (b *CNNBuilder) OutputShape() *Shape {
+	s := b.Input
+	for i, spec := range b.specs {
+		s = spec.OutputShape(s)
+		if s.Ch <= 0 || s.Row <= 0 || s.Col <= 0 {
+			panic(fmt.Sprintf("layer %d: output shape %v should be positive", i, *s))
+		}
+	}
+	return s
+}
+
+func (b *CNNBuilder) Build() *SimpleCNN {
+	out := b.OutputShape()
+
+	layers := make([]ImageLayer, 0, len(b.specs))
+	s := b.Input
+	for _, spec := range b.specs {
+		layers = append(layers, spec.Build(s, b))
+		s = spec.OutputShape(s)
+	}
+
+	head := batch.NewSequential(out.Ch*out.Row*out.Col, b.Optimizer).InitStd(b.WeightInitStd)
+	for _, spec := range b.head {
+		head.Add(spec)
+	}
+
+	return &SimpleCNN{
+		imageLayers: layers,
+		nn:          head.Build(),
+	}
+}
+
+func checkStride(stride int) {
+	if stride <= 0 {
+		panic(fmt.Sprintf("stride should be positive but got %d", stride))
+	}
+}
+
+func (b *CNNBuilder) newOptimizer() optimizer.Optimizer {
+	if b.Optimizer == nil {
+		return nil
+	}
+	return b.Optimizer()
+}
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"flag"
 	"fmt"
 	"math/rand"
 	"os"
@@ -8,14 +9,29 @@ import (
 	"time"
 
 	"github.com/ajiyoshi/gocnn"
+	"github.com/ajiyoshi/gocnn/batch"
+	"github.com/ajiyoshi/gocnn/config"
+	"github.com/ajiyoshi/gocnn/dataset"
 	"github.com/ajiyoshi/gocnn/mnist"
+	"github.com/ajiyoshi/gocnn/optimizer"
+	"github.com/ajiyoshi/gocnn/train"
 )
 
 func init() {
 	rand.Seed(time.Now().Unix())
 }
 
+var (
+	configPath  = flag.String("config", "", "JSON or YAML file of the network and the training settings")
+	datasetName = flag.String("dataset", "mnist", "mnist, fashion, kmnist or emnist-{byclass,bymerge,balanced,letters,digits,mnist}")
+	dataDir     = flag.String("data", "../..", "directory of the IDX files")
+	imageDir    = flag.String("images", "", "directory of a subdirectory of images per class to train on instead of the IDX files")
+	channels    = flag.Int("channels", 1, "1 for grayscale or 3 for RGB images of -images")
+	imageSize   = flag.Int("size", 28, "size to resize the images of -images to")
+)
+
 func main() {
+	flag.Parse()
 	cpuprofile := "mycpu.prof"
 	f, err := os.Create(cpuprofile)
 	if err != nil {
@@ -32,29 +48,86 @@ func main() {
 	}
 }
 
+func open() (dataset.Dataset, func(), error) {
+	if *imageDir != "" {
+		d, err := dataset.NewImageDir(*imageDir, *channels, *imageSize, *imageSize)
+		return d, func() {}, err
+	}
+	variant, err := mnist.LookupVariant(*datasetName)
+	if err != nil {
+		return nil, nil, err
+	}
+	m, err := variant.Open(*dataDir, true)
+	if err != nil {
+		return nil, nil, err
+	}
+	return m, m.Close, nil
+}
+
 func run() error {
-	m, err := mnist.NewMnist("../../train-images-idx3-ubyte.idx", "../../train-labels-idx1-ubyte.idx")
+	m, closer, err := open()
 	if err != nil {
 		return err
 	}
-	defer m.Close()
+	defer closer()
 
-	len := m.Images.Rows * m.Images.Cols
 	N := 50
-	shape := gocnn.NewShape(N, 1, m.Images.Rows, m.Images.Cols)
-
-	cnn := gocnn.NewSimpleConvNet(shape)
-	buf := mnist.NewTrainBuffer(N, len, 10)
-	for i := 0; i < 30; i++ {
-		index := rand.Intn(m.Images.Num - N)
-		at := mnist.Seq(index, N)
-		buf.Load(m, at)
-		x, t := buf.Bake()
-		img := gocnn.NewReshaped(shape, x)
-		cnn.Train(img, t)
-		loss := cnn.Loss(img, t)
-		fmt.Printf("%f, %f\n", loss, cnn.Accracy(img, t))
-	}
-
-	return nil
+	iterations := 30
+	epochs := 1
+	s := m.Shape()
+	shape := gocnn.NewShape(N, s[0], s[1], s[2])
+
+	cnn := gocnn.NewCNNBuilder(shape, optimizer.NewAdam(0.001, 0.9, 0.999)).
+		Conv(30, 5, 1, 0).ReLU().Pool(2, 2).
+		Dense(batch.Affine(100), batch.ReLU(), batch.Affine(m.Classes()), batch.ReLU()).
+		Build()
+	var sched *optimizer.Scheduler
+	if *configPath != "" {
+		conf, err := config.Load(*configPath)
+		if err != nil {
+			return err
+		}
+		if err := conf.CheckInput(s); err != nil {
+			return err
+		}
+		if conf.Training.BatchSize == 0 {
+			conf.Training.BatchSize = N
+		}
+		sched = conf.Scheduler()
+		if cnn, err = conf.BuildCNN(sched); err != nil {
+			return err
+		}
+		N = conf.Training.BatchSize
+		shape = gocnn.NewShape(N, conf.Input.Channels, conf.Input.Rows, conf.Input.Cols)
+		if conf.Training.Iterations > 0 {
+			iterations = conf.Training.Iterations
+		}
+		if conf.Training.Epochs > 0 {
+			epochs = conf.Training.Epochs
+		}
+	}
+
+	// train on a random subset of iterations batches, or on every example
+	// of a smaller dataset
+	size := iterations * N
+	if size > m.Len() {
+		size = m.Len()
+	}
+	if size == 0 {
+		return fmt.Errorf("no example to train on")
+	}
+	index := rand.Perm(m.Len())[:size]
+	sampler := dataset.NewShuffledIndexSampler(index, N, rand.Int63())
+	loader := train.NewPrefetcher(m, sampler, 2, 4)
+	defer loader.Close()
+	augment := gocnn.Compose{&gocnn.Shift{Max: 2}, &gocnn.Rotate{MaxDegrees: 10}}
+	augmented := train.NewAugmented(loader, augment, shape.Ch, shape.Row, shape.Col, rand.Int63())
+	model := train.NewCNN(cnn, shape.Ch, shape.Row, shape.Col)
+	trainer := train.NewTrainer(model, augmented, epochs).
+		Add(&train.Logger{W: os.Stdout, Every: 1})
+	if sched != nil {
+		trainer.Add(train.StepScheduler(sched))
+	}
+	_, err = trainer.Run()
+	return err
 }
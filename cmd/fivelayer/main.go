@@ -1,20 +1,33 @@
 package main
 
 import (
-	"fmt"
+	"flag"
 	"math/rand"
+	"os"
 	"time"
 
 	"github.com/ajiyoshi/gocnn/batch"
+	"github.com/ajiyoshi/gocnn/config"
+	"github.com/ajiyoshi/gocnn/dataset"
+	"github.com/ajiyoshi/gocnn/eval"
 	"github.com/ajiyoshi/gocnn/mnist"
 	"github.com/ajiyoshi/gocnn/optimizer"
+	"github.com/ajiyoshi/gocnn/train"
 )
 
 func init() {
 	rand.Seed(time.Now().Unix())
 }
 
+var (
+	configPath  = flag.String("config", "", "JSON or YAML file of the network and the training settings")
+	datasetName = flag.String("dataset", "mnist", "mnist, fashion, kmnist or emnist-{byclass,bymerge,balanced,letters,digits,mnist}")
+	dataDir     = flag.String("data", "../..", "directory of the IDX files")
+	reportPath  = flag.String("report", "", "JSON file to write the metrics of the test set to")
+)
+
 func main() {
+	flag.Parse()
 	err := run()
 	if err != nil {
 		panic(err)
@@ -22,81 +35,117 @@ func main() {
 }
 
 func run() error {
-	m, err := mnist.NewMnist("../../train-images-idx3-ubyte.idx", "../../train-labels-idx1-ubyte.idx")
+	variant, err := mnist.LookupVariant(*datasetName)
+	if err != nil {
+		return err
+	}
+	m, err := variant.Open(*dataDir, true)
 	if err != nil {
 		return err
 	}
 	defer m.Close()
 
-	input := m.Images.Rows * m.Images.Cols
+	input := dataset.Size(m)
 	hidden := 100
-	output := 10
-	optimizer := optimizer.NewMomentumFactory(0.1, 0.1)
-
-	layer := NewFiveLayerNN(input, hidden, output, optimizer)
-	nn := batch.NewNeuralNet(layer)
+	output := m.Classes()
+	factory := optimizer.NewMomentumFactory(0.1, 0.1)
 
 	batchSize := 200
-	buf := mnist.NewTrainBuffer(batchSize, input, 10)
-	for i := 0; i < 3000; i++ {
-		at := mnist.RandamSeq(batchSize, m.Images.Num)
-		buf.Load(m, at)
-		x, t := buf.Bake()
-		loss := nn.Train(x, t)
-		if i%100 == 0 {
-			fmt.Printf("%f, %f\n", loss, nn.Accracy(x, t))
+	epochs := 50
+	validation := 0.1
+	seed := rand.Int63()
+	var conf *config.Config
+	if *configPath != "" {
+		if conf, err = config.Load(*configPath); err != nil {
+			return err
+		}
+		if conf.Training.BatchSize > 0 {
+			batchSize = conf.Training.BatchSize
+		}
+		if conf.Training.Epochs > 0 {
+			epochs = conf.Training.Epochs
+		}
+		if conf.Training.Validation > 0 {
+			validation = conf.Training.Validation
+		}
+		if conf.Training.Seed != 0 {
+			seed = conf.Training.Seed
 		}
 	}
 
-	m2, err := mnist.NewMnist("../../t10k-images-idx3-ubyte.idx", "../../t10k-labels-idx1-ubyte.idx")
+	trainIndex, validIndex := dataset.StratifiedSplit(m, validation, seed)
+	sampler := dataset.NewShuffledIndexSampler(trainIndex, batchSize, seed)
+
+	var nn *batch.NeuralNet
+	var sched *optimizer.Scheduler
+	if conf != nil {
+		if err := conf.CheckInput(m.Shape()); err != nil {
+			return err
+		}
+		sched = conf.Scheduler()
+		if nn, err = conf.BuildNeuralNet(sched); err != nil {
+			return err
+		}
+	} else {
+		// standardize the pixels with the statistics of the training set
+		stats, err := train.Fit(train.NewDatasetLoader(m, dataset.NewIndexSampler(trainIndex, 1000)), false)
+		if err != nil {
+			return err
+		}
+		nn = batch.NewSequential(input, factory).
+			InitStd(WeightInitStd).
+			Add(batch.Normalization(stats.Standardize(1e-2))).
+			Add(batch.Affine(hidden)).Add(batch.ReLU()).
+			Add(batch.Affine(hidden)).Add(batch.ReLU()).
+			Add(batch.Affine(hidden)).Add(batch.ReLU()).
+			Add(batch.Affine(output)).Add(batch.ReLU()).
+			Build()
+	}
+	trainer := train.NewTrainer(nn, train.NewDatasetLoader(m, sampler), epochs).
+		Validate(train.NewDatasetLoader(m, dataset.NewIndexSampler(validIndex, 1000))).
+		Add(&train.Logger{W: os.Stdout, Every: 100}).
+		Add(train.NewEarlyStopping(nn, 3))
+	if sched != nil {
+		trainer.Add(train.StepScheduler(sched))
+	}
+	if _, err := trainer.Run(); err != nil {
+		return err
+	}
+
+	m2, err := variant.Open(*dataDir, false)
 	if err != nil {
 		return err
 	}
 	defer m2.Close()
 
-	buf = mnist.NewTrainBuffer(10000, input, 10)
-	buf.Load(m2, mnist.Seq(0, 10000))
-	x, t := buf.Bake()
-	fmt.Printf("test:%f, %f\n", nn.Loss(x, t), nn.Accracy(x, t))
-
+	testLoader := train.NewDatasetLoader(m2, dataset.NewSampler(m2.Len(), 1000))
+	e, err := eval.Evaluate(nn, testLoader, output, 1, 3)
+	if err != nil {
+		return err
+	}
+	for i := 0; i < output; i++ {
+		e.Names = append(e.Names, m2.ClassName(i))
+	}
+	report := e.Report()
+	if err := report.WriteTable(os.Stdout); err != nil {
+		return err
+	}
+	if *reportPath != "" {
+		return writeReport(*reportPath, report)
+	}
 	return nil
 }
 
-type FiveLayerNN struct {
-	affine1 *batch.AffineLayer
-	relu1   *batch.ReLULayer
-	affine2 *batch.AffineLayer
-	relu2   *batch.ReLULayer
-	affine3 *batch.AffineLayer
-	relu3   *batch.ReLULayer
-	affine4 *batch.AffineLayer
-	relu4   *batch.ReLULayer
-	last    *batch.SoftMaxWithLoss
-}
-
-const WeightInitStd = 0.1
-
-var _ batch.NeuralNetLayers = (*FiveLayerNN)(nil)
-
-func NewFiveLayerNN(input_size, hidden_size, output_size int, f optimizer.OptimizerFactory) *FiveLayerNN {
-	return &FiveLayerNN{
-		affine1: batch.NewAffine(WeightInitStd, input_size, hidden_size, f()),
-		relu1:   batch.NewReLU(),
-		affine2: batch.NewAffine(WeightInitStd, hidden_size, hidden_size, f()),
-		relu2:   batch.NewReLU(),
-		affine3: batch.NewAffine(WeightInitStd, hidden_size, hidden_size, f()),
-		relu3:   batch.NewReLU(),
-		affine4: batch.NewAffine(WeightInitStd, hidden_size, output_size, f()),
-		relu4:   batch.NewReLU(),
-		last:    batch.NewSoftMaxWithLoss(),
+func writeReport(path string, r *eval.Report) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
 	}
-}
-
-func (nn *FiveLayerNN) Layers() []batch.Layer {
-	return []batch.Layer{
-		nn.affine1, nn.relu1, nn.affine2, nn.relu2, nn.affine3, nn.relu3, nn.affine4, nn.relu4,
+	if err := r.WriteJSON(f); err != nil {
+		f.Close()
+		return err
 	}
+	return f.Close()
 }
-func (nn *FiveLayerNN) Last() batch.LastLayer {
-	return nn.last
-}
+
+const WeightInitStd = 0.1
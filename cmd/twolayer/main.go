@@ -41,7 +41,9 @@ func run() error {
 	for i := 0; i < 100; i++ {
 		index := rand.Intn(m.Images.Num - rows)
 		at := mnist.Seq(index, rows)
-		buf.Load(m, at)
+		if err := buf.Read(m, at); err != nil {
+			return err
+		}
 		x, t := buf.Bake()
 		fmt.Printf("x(%d) %s\n", index, matrix.Summary(x))
 		fmt.Printf("W(%d) %s\n", index, matrix.Summary(layer.Affine1.Weight))
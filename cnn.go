@@ -1,17 +1,23 @@
 package gocnn
 
 import (
+	"strconv"
+
 	mat "github.com/gonum/matrix/mat64"
 
 	"github.com/ajiyoshi/gocnn/batch"
+	"github.com/ajiyoshi/gocnn/optimizer"
 )
 
 type SimpleCNN struct {
 	imageLayers   []ImageLayer
 	imageToMatrix ImageToMatrix
 	nn            *batch.NeuralNet
+	optimizer     *optimizer.NetworkOptimizer
 }
 
+var _ optimizer.Parameterized = (*SimpleCNN)(nil)
+
 func (cnn *SimpleCNN) Forward(img Image) mat.Matrix {
 	for _, layer := range cnn.imageLayers {
 		img = layer.Forward(img)
@@ -42,7 +48,30 @@ func (cnn *SimpleCNN) Accracy(img Image, t mat.Matrix) float64 {
 	x := cnn.Forward(img)
 	return cnn.nn.Accracy(x, t)
 }
+
+// SetOptimizer makes Update step all parameters of the image layers and the
+// dense layers with o at once instead of calling the optimizer of each layer.
+func (cnn *SimpleCNN) SetOptimizer(o *optimizer.NetworkOptimizer) {
+	cnn.optimizer = o
+}
+
+// Params are the parameters of the image layers named like "0.weight"
+// followed by those of the dense layers named like "nn.0.weight".
+func (cnn *SimpleCNN) Params() []optimizer.Param {
+	var ret []optimizer.Param
+	for i, layer := range cnn.imageLayers {
+		if p, ok := layer.(optimizer.Parameterized); ok {
+			ret = append(ret, optimizer.Prefix(strconv.Itoa(i), p.Params())...)
+		}
+	}
+	return append(ret, optimizer.Prefix("nn", cnn.nn.Params())...)
+}
+
 func (cnn *SimpleCNN) Update() {
+	if cnn.optimizer != nil {
+		cnn.optimizer.Step(cnn.Params())
+		return
+	}
 	for _, layer := range cnn.imageLayers {
 		layer.Update()
 	}
@@ -65,6 +94,9 @@ var (
 	_ ImageLayer = (*Convolution)(nil)
 	_ ImageLayer = (*Pooling)(nil)
 	_ ImageLayer = (*ReLU)(nil)
+	_ ImageLayer = (*Normalize)(nil)
+
+	_ optimizer.Parameterized = (*Convolution)(nil)
 )
 
 type ImageToMatrix struct {
@@ -0,0 +1,83 @@
+package gocnn
+
+import (
+	"math"
+	"testing"
+
+	mat "github.com/gonum/matrix/mat64"
+
+	"github.com/ajiyoshi/gocnn/batch"
+	"github.com/ajiyoshi/gocnn/optimizer"
+)
+
+func TestCNNBuilder(t *testing.T) {
+	s := NewShape(2, 1, 8, 6)
+	b := NewCNNBuilder(s, optimizer.NewAdam(0.01, 0.9, 0.999)).
+		InitStd(0.1).
+		Conv(4, 3, 1, 1).ReLU().Pool(2, 2).
+		Conv(8, 3, 1, 0).ReLU().
+		Dense(batch.Affine(10), batch.ReLU(), batch.Affine(3))
+
+	out := b.OutputShape()
+	expect := NewShape(2, 8, 2, 1)
+	if *out != *expect {
+		t.Fatalf("expect %v but got %v", *expect, *out)
+	}
+
+	cnn := b.Build()
+	img := NewRandomImage(s, 1)
+	y := cnn.Predict(img)
+	if r, c := y.Dims(); r != 2 || c != 3 {
+		t.Fatalf("expect (2, 3) but got (%d, %d)", r, c)
+	}
+
+	tt := mat.NewDense(2, 3, []float64{
+		1, 0, 0,
+		0, 0, 1,
+	})
+	before := cnn.Loss(img, tt)
+	for i := 0; i < 20; i++ {
+		cnn.Train(img, tt)
+	}
+	if after := cnn.Loss(img, tt); after >= before {
+		t.Fatalf("loss should decrease but got %v -> %v", before, after)
+	}
+}
+
+func TestCNNBuilderBadShape(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Fatalf("should panic when the image gets smaller than the filter")
+		}
+	}()
+	NewCNNBuilder(NewShape(1, 1, 4, 4), nil).Conv(1, 5, 1, 0).OutputShape()
+}
+
+func TestCNNBuilderNormalize(t *testing.T) {
+	s := NewShape(3, 2, 2, 2)
+	img := NewRandomImage(s, 1)
+	stats := batch.NewStats(8, false)
+	stats.Add(img.Matrix())
+
+	cnn := NewCNNBuilder(s, nil).
+		Normalize(stats.PerChannel(2, 0)).
+		Dense(batch.Affine(2)).
+		Build()
+	if len(cnn.Params()) != 2 {
+		t.Fatalf("expect 2 params but got %d", len(cnn.Params()))
+	}
+
+	out := cnn.imageLayers[0].Forward(img)
+	for ch := 0; ch < 2; ch++ {
+		sum, sumSq := 0.0, 0.0
+		for n := 0; n < 3; n++ {
+			for _, v := range mat.DenseCopyOf(out.Channels(n)[ch]).RawMatrix().Data {
+				sum += v
+				sumSq += v * v
+			}
+		}
+		if mean, variance := sum/12, sumSq/12-sum*sum/144; math.Abs(mean) > 1e-9 || math.Abs(variance-1) > 1e-9 {
+			t.Fatalf("channel %d expect (0, 1) but got (%v, %v)", ch, mean, variance)
+		}
+	}
+}
@@ -0,0 +1,205 @@
+package config
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/ajiyoshi/gocnn"
+	"github.com/ajiyoshi/gocnn/batch"
+	"github.com/ajiyoshi/gocnn/optimizer"
+)
+
+// Scheduler is a new scheduler of the configured learning-rate schedule, or
+// nil without one. Pass it to the Build methods and Step it every epoch.
+func (c *Config) Scheduler() *optimizer.Scheduler {
+	s := c.Optimizer.Schedule
+	if s == nil {
+		return nil
+	}
+	switch s.Type {
+	case "step":
+		return optimizer.NewScheduler(&optimizer.StepDecay{Step: s.Step, Gamma: s.Gamma})
+	case "exponential":
+		return optimizer.NewScheduler(&optimizer.ExponentialDecay{Gamma: s.Gamma})
+	case "cosine":
+		return optimizer.NewScheduler(&optimizer.CosineAnnealing{Period: s.Period, Mult: s.Mult, Min: s.Min})
+	case "warmup":
+		return optimizer.NewScheduler(&optimizer.LinearWarmup{Epochs: s.Epochs})
+	}
+	panic(fmt.Sprintf("unknown schedule %q", s.Type))
+}
+
+// OptimizerFactory creates the configured optimizers, registering them to
+// the scheduler s unless it is nil. Hyperparameters left 0 get the usual
+// defaults.
+func (c *Config) OptimizerFactory(s *optimizer.Scheduler) optimizer.OptimizerFactory {
+	o := &c.Optimizer
+	f := o.factory()
+	if o.WeightDecay > 0 && o.Type != "adamw" {
+		f = optimizer.NewWeightDecay(f, o.WeightDecay)
+	}
+	if o.ClipValue > 0 {
+		f = optimizer.NewClipByValue(f, o.ClipValue)
+	}
+	if o.ClipNorm > 0 {
+		f = optimizer.NewClipByNorm(f, o.ClipNorm)
+	}
+	if s != nil {
+		f = s.Factory(f)
+	}
+	return f
+}
+
+func (o *Optimizer) factory() optimizer.OptimizerFactory {
+	switch o.Type {
+	case "sgd":
+		return optimizer.NewSGDFactory(o.Lr)
+	case "momentum":
+		return optimizer.NewMomentumFactory(o.Lr, orFloat(o.Momentum, 0.9))
+	case "nesterov":
+		return optimizer.NewNesterovFactory(o.Lr, orFloat(o.Momentum, 0.9))
+	case "adagrad":
+		return optimizer.NewAdaGradFactory(o.Lr)
+	case "rmsprop":
+		return optimizer.NewRMSPropFactory(o.Lr, orFloat(o.Decay, 0.99))
+	case "adadelta":
+		return optimizer.NewAdaDeltaFactory(o.Lr, orFloat(o.Decay, 0.95))
+	case "adam":
+		return optimizer.NewAdam(o.Lr, orFloat(o.Beta1, 0.9), orFloat(o.Beta2, 0.999))
+	case "adamw":
+		return optimizer.NewAdamW(o.Lr, orFloat(o.Beta1, 0.9), orFloat(o.Beta2, 0.999), orFloat(o.WeightDecay, 0.01))
+	}
+	panic(fmt.Sprintf("unknown optimizer %q", o.Type))
+}
+
+// std is the standard deviation of the initial weights of a layer with
+// fanIn inputs.
+func (i *Init) std(fanIn int) float64 {
+	switch i.Type {
+	case "he":
+		return math.Sqrt(2 / float64(fanIn))
+	case "xavier":
+		return math.Sqrt(1 / float64(fanIn))
+	}
+	if i.Std == 0 {
+		return gocnn.WeightInitStd
+	}
+	return i.Std
+}
+
+// layerFactory is the factory given to the layers, which is nil when the
+// network is stepped as a whole to clip by the global norm.
+func (c *Config) layerFactory(f optimizer.OptimizerFactory) optimizer.OptimizerFactory {
+	if c.Optimizer.ClipNorm > 0 {
+		return nil
+	}
+	return f
+}
+
+func (c *Config) networkOptimizer(f optimizer.OptimizerFactory) *optimizer.NetworkOptimizer {
+	if c.Optimizer.ClipNorm == 0 {
+		return nil
+	}
+	return optimizer.NewNetworkOptimizer(f)
+}
+
+// BuildNeuralNet builds a network of affine and relu layers fed with the
+// flattened input. s may be nil.
+func (c *Config) BuildNeuralNet(s *optimizer.Scheduler) (*batch.NeuralNet, error) {
+	if err := c.Validate(); err != nil {
+		return nil, err
+	}
+	f := c.OptimizerFactory(s)
+	seq := batch.NewSequential(c.InputSize(), c.layerFactory(f))
+	for i, l := range c.Layers {
+		if l.Type == "conv" || l.Type == "pool" {
+			return nil, invalid(fmt.Sprintf("layers[%d].type", i), "%s is not supported by a neural net", l.Type)
+		}
+		seq.Add(c.denseSpec(l, seq.OutputSize()))
+	}
+	nn := seq.Build()
+	if o := c.networkOptimizer(f); o != nil {
+		nn.SetOptimizer(o)
+	}
+	return nn, nil
+}
+
+// BuildCNN builds a network taking batches of Training.BatchSize images.
+// s may be nil.
+func (c *Config) BuildCNN(s *optimizer.Scheduler) (*gocnn.SimpleCNN, error) {
+	if err := c.Validate(); err != nil {
+		return nil, err
+	}
+	if !c.IsImage() {
+		return nil, invalid("input", "a cnn needs an image input")
+	}
+	if c.Training.BatchSize <= 0 {
+		return nil, invalid("training.batch_size", "should be positive but got %d", c.Training.BatchSize)
+	}
+	in := &c.Input
+	f := c.OptimizerFactory(s)
+	b := gocnn.NewCNNBuilder(gocnn.NewShape(c.Training.BatchSize, in.Channels, in.Rows, in.Cols), c.layerFactory(f))
+
+	shape := b.Input
+	head := false
+	input := 0
+	for i, l := range c.Layers {
+		if l.Type == "affine" && !head {
+			head = true
+			input = shape.Ch * shape.Row * shape.Col
+		}
+		if head {
+			spec := c.denseSpec(l, input)
+			input = spec.OutputSize(input)
+			b.Dense(spec)
+			continue
+		}
+		var spec gocnn.ImageLayerSpec
+		switch l.Type {
+		case "conv":
+			spec = &gocnn.ConvSpec{
+				Filters: l.Filters,
+				Row:     l.Size,
+				Col:     l.Size,
+				Stride:  orInt(l.Stride, 1),
+				Pad:     l.Pad,
+				Std:     c.Init.std(shape.Ch * l.Size * l.Size),
+			}
+		case "pool":
+			spec = &gocnn.PoolSpec{Row: l.Size, Col: l.Size, Stride: orInt(l.Stride, l.Size), Pad: l.Pad}
+		case "relu":
+			spec = &gocnn.ReLUSpec{}
+		}
+		shape = spec.OutputShape(shape)
+		if shape.Row <= 0 || shape.Col <= 0 {
+			return nil, invalid(fmt.Sprintf("layers[%d]", i), "output shape %dx%d should be positive", shape.Row, shape.Col)
+		}
+		b.Add(spec)
+	}
+	cnn := b.Build()
+	if o := c.networkOptimizer(f); o != nil {
+		cnn.SetOptimizer(o)
+	}
+	return cnn, nil
+}
+
+func (c *Config) denseSpec(l Layer, input int) batch.LayerSpec {
+	if l.Type == "relu" {
+		return batch.ReLU()
+	}
+	return &batch.AffineSpec{Output: l.Output, Std: c.Init.std(input)}
+}
+
+func orInt(x, def int) int {
+	if x == 0 {
+		return def
+	}
+	return x
+}
+
+func orFloat(x, def float64) float64 {
+	if x == 0 {
+		return def
+	}
+	return x
+}
@@ -0,0 +1,371 @@
+/*
+Package config reads the architecture and hyperparameters of a network from
+JSON or YAML:
+
+	input: {channels: 1, rows: 28, cols: 28}
+	layers:
+	  - {type: conv, filters: 30, size: 5}
+	  - {type: relu}
+	  - {type: pool, size: 2}
+	  - {type: affine, output: 100}
+	  - {type: relu}
+	  - {type: affine, output: 10}
+	optimizer: {type: adam, lr: 0.001}
+	init: {type: normal, std: 0.01}
+	training: {batch_size: 100, epochs: 10}
+*/
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+type Config struct {
+	Input     Input     `json:"input" yaml:"input"`
+	Layers    []Layer   `json:"layers" yaml:"layers"`
+	Optimizer Optimizer `json:"optimizer" yaml:"optimizer"`
+	Init      Init      `json:"init" yaml:"init"`
+	Training  Training  `json:"training" yaml:"training"`
+}
+
+// Input is the shape of one sample. A flat input of Size features can be
+// given instead of Channels, Rows and Cols.
+type Input struct {
+	Size     int `json:"size" yaml:"size"`
+	Channels int `json:"channels" yaml:"channels"`
+	Rows     int `json:"rows" yaml:"rows"`
+	Cols     int `json:"cols" yaml:"cols"`
+}
+
+// Layer is one of
+//
+//	conv   : filters, size, stride (1), pad (0)
+//	pool   : size, stride (size), pad (0)
+//	affine : output
+//	relu
+type Layer struct {
+	Type    string `json:"type" yaml:"type"`
+	Output  int    `json:"output" yaml:"output"`
+	Filters int    `json:"filters" yaml:"filters"`
+	Size    int    `json:"size" yaml:"size"`
+	Stride  int    `json:"stride" yaml:"stride"`
+	Pad     int    `json:"pad" yaml:"pad"`
+}
+
+// Optimizer is an optimizer of the package optimizer by Type (sgd, momentum,
+// nesterov, adagrad, rmsprop, adadelta, adam or adamw) with its
+// hyperparameters, and the regularisation applied to it.
+type Optimizer struct {
+	Type        string    `json:"type" yaml:"type"`
+	Lr          float64   `json:"lr" yaml:"lr"`
+	Momentum    float64   `json:"momentum" yaml:"momentum"`
+	Beta1       float64   `json:"beta1" yaml:"beta1"`
+	Beta2       float64   `json:"beta2" yaml:"beta2"`
+	Decay       float64   `json:"decay" yaml:"decay"`
+	WeightDecay float64   `json:"weight_decay" yaml:"weight_decay"`
+	ClipValue   float64   `json:"clip_value" yaml:"clip_value"`
+	ClipNorm    float64   `json:"clip_norm" yaml:"clip_norm"`
+	Schedule    *Schedule `json:"schedule" yaml:"schedule"`
+}
+
+// Schedule is a learning-rate schedule by Type (step, exponential, cosine
+// or warmup).
+type Schedule struct {
+	Type   string  `json:"type" yaml:"type"`
+	Step   int     `json:"step" yaml:"step"`
+	Gamma  float64 `json:"gamma" yaml:"gamma"`
+	Period int     `json:"period" yaml:"period"`
+	Mult   int     `json:"mult" yaml:"mult"`
+	Min    float64 `json:"min" yaml:"min"`
+	Epochs int     `json:"epochs" yaml:"epochs"`
+}
+
+// Init is the initialiser of the weights by Type: normal with Std, or he
+// and xavier which derive the standard deviation from the input size of
+// each layer.
+type Init struct {
+	Type string  `json:"type" yaml:"type"`
+	Std  float64 `json:"std" yaml:"std"`
+}
+
+type Training struct {
+	BatchSize  int     `json:"batch_size" yaml:"batch_size"`
+	Epochs     int     `json:"epochs" yaml:"epochs"`
+	Iterations int     `json:"iterations" yaml:"iterations"`
+	Seed       int64   `json:"seed" yaml:"seed"`
+	Validation float64 `json:"validation" yaml:"validation"`
+}
+
+// ValidationError tells which entry of the config is wrong, e.g.
+// "layers[2].size".
+type ValidationError struct {
+	Path string
+	Msg  string
+}
+
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Path, e.Msg)
+}
+
+func invalid(path, format string, a ...interface{}) error {
+	return &ValidationError{Path: path, Msg: fmt.Sprintf(format, a...)}
+}
+
+// Load reads a config from a .json, .yaml or .yml file and validates it.
+func Load(path string) (*Config, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		return ParseJSON(data)
+	case ".yaml", ".yml":
+		return ParseYAML(data)
+	}
+	return nil, fmt.Errorf("%s: unknown config format", path)
+}
+
+func ParseJSON(data []byte) (*Config, error) {
+	var c Config
+	d := json.NewDecoder(bytes.NewReader(data))
+	d.DisallowUnknownFields()
+	if err := d.Decode(&c); err != nil {
+		return nil, err
+	}
+	return &c, c.Validate()
+}
+
+func ParseYAML(data []byte) (*Config, error) {
+	var c Config
+	if err := yaml.UnmarshalStrict(data, &c); err != nil {
+		return nil, err
+	}
+	return &c, c.Validate()
+}
+
+// IsImage tells whether the input is given as an image.
+func (c *Config) IsImage() bool {
+	return c.Input.Size == 0
+}
+
+// InputSize is the number of features of one sample.
+func (c *Config) InputSize() int {
+	if c.IsImage() {
+		return c.Input.Channels * c.Input.Rows * c.Input.Cols
+	}
+	return c.Input.Size
+}
+
+// CheckInput tells whether a dataset of samples of the shape fits the
+// input. A flat input takes samples of any shape of Size features.
+func (c *Config) CheckInput(shape []int) error {
+	in := &c.Input
+	if !c.IsImage() {
+		n := 1
+		for _, d := range shape {
+			n *= d
+		}
+		if n != in.Size {
+			return invalid("input.size", "should be %d to fit the dataset of shape %v", n, shape)
+		}
+		return nil
+	}
+	if len(shape) != 3 || shape[0] != in.Channels || shape[1] != in.Rows || shape[2] != in.Cols {
+		return invalid("input", "shape %dx%dx%d does not fit the dataset of shape %v", in.Channels, in.Rows, in.Cols, shape)
+	}
+	return nil
+}
+
+func (c *Config) Validate() error {
+	if err := c.Input.validate(); err != nil {
+		return err
+	}
+	if len(c.Layers) == 0 {
+		return invalid("layers", "should have at least one layer")
+	}
+	dense := false
+	for i, l := range c.Layers {
+		path := fmt.Sprintf("layers[%d]", i)
+		if err := l.validate(path); err != nil {
+			return err
+		}
+		switch l.Type {
+		case "conv", "pool":
+			if !c.IsImage() {
+				return invalid(path+".type", "%s needs an image input", l.Type)
+			}
+			if dense {
+				return invalid(path+".type", "%s should come before affine layers", l.Type)
+			}
+		case "affine":
+			dense = true
+		}
+	}
+	if err := c.Optimizer.validate("optimizer"); err != nil {
+		return err
+	}
+	if err := c.Init.validate("init"); err != nil {
+		return err
+	}
+	return c.Training.validate("training")
+}
+
+func (in *Input) validate() error {
+	if in.Size < 0 {
+		return invalid("input.size", "should be positive but got %d", in.Size)
+	}
+	if in.Size > 0 {
+		if in.Channels != 0 || in.Rows != 0 || in.Cols != 0 {
+			return invalid("input", "either size or channels, rows and cols should be given")
+		}
+		return nil
+	}
+	for _, x := range []struct {
+		name string
+		v    int
+	}{{"channels", in.Channels}, {"rows", in.Rows}, {"cols", in.Cols}} {
+		if x.v <= 0 {
+			return invalid("input."+x.name, "should be positive but got %d", x.v)
+		}
+	}
+	return nil
+}
+
+func (l *Layer) validate(path string) error {
+	positive := func(name string, v int) error {
+		if v <= 0 {
+			return invalid(path+"."+name, "should be positive but got %d", v)
+		}
+		return nil
+	}
+	notNegative := func(name string, v int) error {
+		if v < 0 {
+			return invalid(path+"."+name, "should not be negative but got %d", v)
+		}
+		return nil
+	}
+	switch l.Type {
+	case "conv":
+		if err := positive("filters", l.Filters); err != nil {
+			return err
+		}
+		if err := positive("size", l.Size); err != nil {
+			return err
+		}
+		if err := notNegative("stride", l.Stride); err != nil {
+			return err
+		}
+		return notNegative("pad", l.Pad)
+	case "pool":
+		if err := positive("size", l.Size); err != nil {
+			return err
+		}
+		if err := notNegative("stride", l.Stride); err != nil {
+			return err
+		}
+		return notNegative("pad", l.Pad)
+	case "affine":
+		return positive("output", l.Output)
+	case "relu":
+		return nil
+	case "":
+		return invalid(path+".type", "should be given")
+	}
+	return invalid(path+".type", "unknown layer type %q", l.Type)
+}
+
+func (o *Optimizer) validate(path string) error {
+	switch o.Type {
+	case "sgd", "momentum", "nesterov", "adagrad", "rmsprop", "adadelta", "adam", "adamw":
+	case "":
+		return invalid(path+".type", "should be given")
+	default:
+		return invalid(path+".type", "unknown optimizer %q", o.Type)
+	}
+	if o.Lr <= 0 {
+		return invalid(path+".lr", "should be positive but got %g", o.Lr)
+	}
+	for _, x := range []struct {
+		name string
+		v    float64
+	}{{"momentum", o.Momentum}, {"beta1", o.Beta1}, {"beta2", o.Beta2}, {"decay", o.Decay}} {
+		if x.v < 0 || x.v >= 1 {
+			return invalid(path+"."+x.name, "should be in [0, 1) but got %g", x.v)
+		}
+	}
+	for _, x := range []struct {
+		name string
+		v    float64
+	}{{"weight_decay", o.WeightDecay}, {"clip_value", o.ClipValue}, {"clip_norm", o.ClipNorm}} {
+		if x.v < 0 {
+			return invalid(path+"."+x.name, "should not be negative but got %g", x.v)
+		}
+	}
+	if o.Schedule != nil {
+		return o.Schedule.validate(path + ".schedule")
+	}
+	return nil
+}
+
+func (s *Schedule) validate(path string) error {
+	switch s.Type {
+	case "step":
+		if s.Step <= 0 {
+			return invalid(path+".step", "should be positive but got %d", s.Step)
+		}
+		fallthrough
+	case "exponential":
+		if s.Gamma <= 0 || s.Gamma > 1 {
+			return invalid(path+".gamma", "should be in (0, 1] but got %g", s.Gamma)
+		}
+	case "cosine":
+		if s.Period <= 0 {
+			return invalid(path+".period", "should be positive but got %d", s.Period)
+		}
+	case "warmup":
+		if s.Epochs <= 0 {
+			return invalid(path+".epochs", "should be positive but got %d", s.Epochs)
+		}
+	case "":
+		return invalid(path+".type", "should be given")
+	default:
+		return invalid(path+".type", "unknown schedule %q", s.Type)
+	}
+	return nil
+}
+
+func (i *Init) validate(path string) error {
+	switch i.Type {
+	case "", "normal":
+		if i.Std < 0 {
+			return invalid(path+".std", "should not be negative but got %g", i.Std)
+		}
+	case "he", "xavier":
+	default:
+		return invalid(path+".type", "unknown initialiser %q", i.Type)
+	}
+	return nil
+}
+
+func (t *Training) validate(path string) error {
+	for _, x := range []struct {
+		name string
+		v    int
+	}{{"batch_size", t.BatchSize}, {"epochs", t.Epochs}, {"iterations", t.Iterations}} {
+		if x.v < 0 {
+			return invalid(path+"."+x.name, "should not be negative but got %d", x.v)
+		}
+	}
+	if t.Validation < 0 || t.Validation >= 1 {
+		return invalid(path+".validation", "should be in [0, 1) but got %g", t.Validation)
+	}
+	return nil
+}
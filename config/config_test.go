@@ -0,0 +1,229 @@
+package config
+
+import (
+	"fmt"
+	"math"
+	"testing"
+
+	mat "github.com/gonum/matrix/mat64"
+
+	"github.com/ajiyoshi/gocnn"
+	"github.com/ajiyoshi/gocnn/batch"
+	"github.com/ajiyoshi/gocnn/optimizer"
+)
+
+func TestLoadCNN(t *testing.T) {
+	c, err := Load("testdata/cnn.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := c.Scheduler()
+	if s == nil {
+		t.Fatalf("expect a scheduler")
+	}
+	cnn, err := c.BuildCNN(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lr := s.LearningRate(); lr != 0.001 {
+		t.Fatalf("expect 0.001 but got %v", lr)
+	}
+
+	ps := cnn.Params()
+	names := []string{"0.weight", "0.bias", "nn.0.weight", "nn.0.bias", "nn.2.weight", "nn.2.bias"}
+	if len(ps) != len(names) {
+		t.Fatalf("expect %d params but got %d", len(names), len(ps))
+	}
+	for i, name := range names {
+		if ps[i].Name != name {
+			t.Fatalf("param %d expect %s but got %s", i, name, ps[i].Name)
+		}
+	}
+	// 4 filters of 8x6 pooled to 4x3
+	if shape := ps[2].Value.Shape(); shape[0] != 4*4*3 || shape[1] != 10 {
+		t.Fatalf("expect (48, 10) but got %v", shape)
+	}
+
+	img := gocnn.NewRandomImage(gocnn.NewShape(5, 1, 8, 6), 1)
+	y := cnn.Predict(img)
+	if r, col := y.Dims(); r != 5 || col != 3 {
+		t.Fatalf("expect (5, 3) but got (%d, %d)", r, col)
+	}
+
+	s.Step()
+	s.Step()
+	if lr := s.LearningRate(); lr != 0.0005 {
+		t.Fatalf("expect 0.0005 but got %v", lr)
+	}
+}
+
+func TestLoadNeuralNet(t *testing.T) {
+	c, err := Load("testdata/nn.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	nn, err := c.BuildNeuralNet(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ls := nn.Layers()
+	if len(ls) != 3 {
+		t.Fatalf("expect 3 layers but got %d", len(ls))
+	}
+	if r, col := ls[2].(*batch.AffineLayer).Weight.Dims(); r != 8 || col != 3 {
+		t.Fatalf("expect (8, 3) but got (%d, %d)", r, col)
+	}
+
+	x := mat.NewDense(2, 12, nil)
+	for i := 0; i < 12; i++ {
+		x.Set(0, i, float64(i)/12)
+		x.Set(1, i, 1-float64(i)/12)
+	}
+	tt := mat.NewDense(2, 3, []float64{
+		1, 0, 0,
+		0, 0, 1,
+	})
+	before := nn.Loss(x, tt)
+	for i := 0; i < 50; i++ {
+		nn.Train(x, tt)
+	}
+	if after := nn.Loss(x, tt); after >= before {
+		t.Fatalf("loss should decrease but got %v -> %v", before, after)
+	}
+
+	if _, err := c.BuildCNN(nil); err == nil {
+		t.Fatalf("a flat input should not build a cnn")
+	}
+}
+
+func TestInit(t *testing.T) {
+	cases := []struct {
+		init   Init
+		fanIn  int
+		expect float64
+	}{
+		{Init{}, 10, gocnn.WeightInitStd},
+		{Init{Type: "normal", Std: 0.5}, 10, 0.5},
+		{Init{Type: "he"}, 8, 0.5},
+		{Init{Type: "xavier"}, 4, 0.5},
+	}
+	for _, c := range cases {
+		if actual := c.init.std(c.fanIn); math.Abs(actual-c.expect) > 1e-12 {
+			t.Fatalf("%v expect %v but got %v", c.init, c.expect, actual)
+		}
+	}
+}
+
+func TestOptimizerFactory(t *testing.T) {
+	cases := []struct {
+		opt    Optimizer
+		expect interface{}
+	}{
+		{Optimizer{Type: "sgd", Lr: 0.1}, &optimizer.SGD{}},
+		{Optimizer{Type: "momentum", Lr: 0.1}, &optimizer.Momentum{}},
+		{Optimizer{Type: "adam", Lr: 0.1}, &optimizer.Adam{}},
+		{Optimizer{Type: "adamw", Lr: 0.1, WeightDecay: 0.1}, &optimizer.DecoupledWeightDecay{}},
+		{Optimizer{Type: "sgd", Lr: 0.1, WeightDecay: 0.1}, &optimizer.WeightDecay{}},
+		{Optimizer{Type: "sgd", Lr: 0.1, WeightDecay: 0.1, ClipValue: 1}, &optimizer.ClipByValue{}},
+		{Optimizer{Type: "sgd", Lr: 0.1, ClipValue: 1, ClipNorm: 1}, &optimizer.ClipByNorm{}},
+	}
+	for _, c := range cases {
+		conf := &Config{Optimizer: c.opt}
+		o := conf.OptimizerFactory(nil)()
+		if a, e := typeName(o), typeName(c.expect); a != e {
+			t.Fatalf("%v expect %s but got %s", c.opt, e, a)
+		}
+		if lr := o.(optimizer.LearningRater).LearningRate(); lr != 0.1 {
+			t.Fatalf("%v expect 0.1 but got %v", c.opt, lr)
+		}
+	}
+}
+
+func typeName(x interface{}) string {
+	return fmt.Sprintf("%T", x)
+}
+
+func TestValidate(t *testing.T) {
+	cases := []struct {
+		yaml string
+		path string
+	}{
+		{`{input: {size: 4}, layers: [{type: affine, output: 0}], optimizer: {type: sgd, lr: 0.1}}`, "layers[0].output"},
+		{`{input: {size: 4}, layers: [{type: conv, filters: 1, size: 3}], optimizer: {type: sgd, lr: 0.1}}`, "layers[0].type"},
+		{`{input: {channels: 1, rows: 4}, layers: [{type: relu}], optimizer: {type: sgd, lr: 0.1}}`, "input.cols"},
+		{`{input: {channels: 1, rows: 4, cols: 4}, layers: [{type: affine, output: 2}, {type: pool, size: 2}], optimizer: {type: sgd, lr: 0.1}}`, "layers[1].type"},
+		{`{input: {size: 4}, layers: [{type: dropout}], optimizer: {type: sgd, lr: 0.1}}`, "layers[0].type"},
+		{`{input: {size: 4}, layers: [{type: relu}], optimizer: {type: lbfgs, lr: 0.1}}`, "optimizer.type"},
+		{`{input: {size: 4}, layers: [{type: relu}], optimizer: {type: sgd}}`, "optimizer.lr"},
+		{`{input: {size: 4}, layers: [{type: relu}], optimizer: {type: adam, lr: 0.1, beta2: 1}}`, "optimizer.beta2"},
+		{`{input: {size: 4}, layers: [{type: relu}], optimizer: {type: sgd, lr: 0.1, schedule: {type: step, gamma: 0.5}}}`, "optimizer.schedule.step"},
+		{`{input: {size: 4}, layers: [{type: relu}], optimizer: {type: sgd, lr: 0.1}, init: {type: zero}}`, "init.type"},
+		{`{input: {size: 4}, layers: [{type: relu}], optimizer: {type: sgd, lr: 0.1}, training: {validation: 1}}`, "training.validation"},
+	}
+	for _, c := range cases {
+		_, err := ParseYAML([]byte(c.yaml))
+		v, ok := err.(*ValidationError)
+		if !ok {
+			t.Fatalf("%s expect a ValidationError but got %v", c.yaml, err)
+		}
+		if v.Path != c.path {
+			t.Fatalf("%s expect %s but got %s", c.yaml, c.path, v.Path)
+		}
+	}
+}
+
+func TestParseStrict(t *testing.T) {
+	if _, err := ParseYAML([]byte(`{input: {size: 4}, layers: [{type: relu, outptu: 3}]}`)); err == nil {
+		t.Fatalf("unknown yaml field should be an error")
+	}
+	if _, err := ParseJSON([]byte(`{"input": {"size": 4}, "layer": []}`)); err == nil {
+		t.Fatalf("unknown json field should be an error")
+	}
+}
+
+func TestBuildCNNBadShape(t *testing.T) {
+	c, err := ParseYAML([]byte(`
+input: {channels: 1, rows: 4, cols: 4}
+layers: [{type: conv, filters: 1, size: 3}, {type: pool, size: 2}, {type: conv, filters: 1, size: 3}]
+optimizer: {type: sgd, lr: 0.1}
+training: {batch_size: 1}
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = c.BuildCNN(nil)
+	if v, ok := err.(*ValidationError); !ok || v.Path != "layers[2]" {
+		t.Fatalf("expect an error at layers[2] but got %v", err)
+	}
+}
+
+func TestCheckInput(t *testing.T) {
+	image := &Config{Input: Input{Channels: 1, Rows: 28, Cols: 28}}
+	flat := &Config{Input: Input{Size: 784}}
+	cases := []struct {
+		title string
+		c     *Config
+		shape []int
+		path  string
+	}{
+		{"image", image, []int{1, 28, 28}, ""},
+		{"image of other size", image, []int{1, 32, 32}, "input"},
+		{"image of other channels", image, []int{3, 28, 28}, "input"},
+		{"image of flat dataset", image, []int{784}, "input"},
+		{"flat of image dataset", flat, []int{1, 28, 28}, ""},
+		{"flat", flat, []int{784}, ""},
+		{"flat of other size", flat, []int{3, 28, 28}, "input.size"},
+	}
+	for _, c := range cases {
+		err := c.c.CheckInput(c.shape)
+		if c.path == "" {
+			if err != nil {
+				t.Fatalf("%s expect no error but got %v", c.title, err)
+			}
+			continue
+		}
+		if v, ok := err.(*ValidationError); !ok || v.Path != c.path {
+			t.Fatalf("%s expect an error at %s but got %v", c.title, c.path, err)
+		}
+	}
+}
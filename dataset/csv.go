@@ -0,0 +1,412 @@
+package dataset
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	mat "github.com/gonum/matrix/mat64"
+)
+
+// Missing is the way ReadCSV handles a missing feature value. A row with a
+// missing target is always dropped.
+type Missing int
+
+const (
+	// FillMean fills a missing number with the mean of its column and
+	// leaves every one-hot column of a missing category at 0.
+	FillMean Missing = iota
+	// FillZero fills a missing number with 0 and leaves every one-hot
+	// column of a missing category at 0.
+	FillZero
+	// DropRow drops the rows with a missing value.
+	DropRow
+)
+
+// CSVOptions tells ReadCSV and ReadRegressionCSV how to read the columns.
+type CSVOptions struct {
+	// Target is the name of the label column, or of the target column of a
+	// regression. Columns are named by their header, or by their number
+	// from 0 with NoHeader.
+	Target string
+	// Categorical are one-hot encoded. Columns with a value which is not a
+	// number are categorical too.
+	Categorical []string
+	Ignore      []string
+	Missing     Missing
+	// NA are the values taken as missing, "", "NA", "NaN" and "?" if nil.
+	NA       []string
+	NoHeader bool
+	Comma    rune
+}
+
+var defaultNA = []string{"", "NA", "NaN", "?"}
+
+// Table is a Dataset of the rows of a CSV file with the class of each row
+// in Labels and the class names in Names. The features are the numeric
+// columns as they are and one column per category of the categorical
+// columns, named like "color=red".
+type Table struct {
+	Columns  []string
+	Features [][]float64
+	Labels   []int
+	Names    []string
+}
+
+// RegressionTable is the rows of a CSV file with the target of each row in
+// Targets, and features as those of a Table. Having no label, it is not a
+// Dataset: a TableLoader loads it.
+type RegressionTable struct {
+	Columns  []string
+	Features [][]float64
+	Targets  []float64
+}
+
+// Batcher is a table giving its rows as batches of features and targets.
+type Batcher interface {
+	Len() int
+	Batch(at []int) (x, y *mat.Dense)
+}
+
+var (
+	_ Dataset = (*Table)(nil)
+	_ Labeler = (*Table)(nil)
+	_ Reader  = (*Table)(nil)
+	_ Batcher = (*Table)(nil)
+	_ Batcher = (*RegressionTable)(nil)
+)
+
+// LoadCSV reads the CSV file of path.
+func LoadCSV(path string, o *CSVOptions) (*Table, error) {
+	var t *Table
+	err := loadCSV(path, func(r io.Reader) (err error) {
+		t, err = ReadCSV(r, o)
+		return err
+	})
+	return t, err
+}
+
+// LoadRegressionCSV reads the CSV file of path.
+func LoadRegressionCSV(path string, o *CSVOptions) (*RegressionTable, error) {
+	var t *RegressionTable
+	err := loadCSV(path, func(r io.Reader) (err error) {
+		t, err = ReadRegressionCSV(r, o)
+		return err
+	})
+	return t, err
+}
+
+func loadCSV(path string, read func(io.Reader) error) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	if err := read(f); err != nil {
+		return fmt.Errorf("%s: %v", path, err)
+	}
+	return nil
+}
+
+// ReadCSV reads a Table from r.
+func ReadCSV(r io.Reader, o *CSVOptions) (*Table, error) {
+	c, err := readCSV(r, o)
+	if err != nil {
+		return nil, err
+	}
+	t := &Table{Columns: c.columns, Features: c.features}
+	t.Names = categories(c.rows, c.target, c.missing)
+	for _, rec := range c.rows {
+		t.Labels = append(t.Labels, indexOf(t.Names, strings.TrimSpace(rec[c.target])))
+	}
+	return t, nil
+}
+
+// ReadRegressionCSV reads a RegressionTable from r, whose target column
+// should be numbers.
+func ReadRegressionCSV(r io.Reader, o *CSVOptions) (*RegressionTable, error) {
+	c, err := readCSV(r, o)
+	if err != nil {
+		return nil, err
+	}
+	t := &RegressionTable{Columns: c.columns, Features: c.features}
+	for i, rec := range c.rows {
+		v, err := strconv.ParseFloat(strings.TrimSpace(rec[c.target]), 64)
+		if err != nil {
+			return nil, fmt.Errorf("line %d, column %q: %v", c.lines[i], o.Target, err)
+		}
+		t.Targets = append(t.Targets, v)
+	}
+	return t, nil
+}
+
+// parsedCSV is a CSV file with the features encoded and the rows kept,
+// whose targets are left to ReadCSV or ReadRegressionCSV.
+type parsedCSV struct {
+	columns  []string
+	features [][]float64
+	// rows are the records kept, read from lines of the file.
+	rows    [][]string
+	lines   []int
+	target  int
+	missing func(string) bool
+}
+
+func readCSV(r io.Reader, o *CSVOptions) (*parsedCSV, error) {
+	cr := csv.NewReader(r)
+	if o.Comma != 0 {
+		cr.Comma = o.Comma
+	}
+	cr.TrimLeadingSpace = true
+	var records [][]string
+	var lines []int
+	for {
+		rec, err := cr.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		line, _ := cr.FieldPos(0)
+		records = append(records, rec)
+		lines = append(lines, line)
+	}
+	if len(records) == 0 {
+		return nil, fmt.Errorf("no row")
+	}
+
+	var header []string
+	if o.NoHeader {
+		for i := range records[0] {
+			header = append(header, strconv.Itoa(i))
+		}
+	} else {
+		header, records, lines = records[0], records[1:], lines[1:]
+	}
+	target := indexOf(header, o.Target)
+	if target < 0 {
+		return nil, fmt.Errorf("no target column %q", o.Target)
+	}
+	for _, name := range append(append([]string(nil), o.Categorical...), o.Ignore...) {
+		if indexOf(header, name) < 0 {
+			return nil, fmt.Errorf("no column %q", name)
+		}
+	}
+
+	na := o.NA
+	if na == nil {
+		na = defaultNA
+	}
+	missing := func(v string) bool {
+		return indexOf(na, strings.TrimSpace(v)) >= 0
+	}
+
+	p := &parsedCSV{target: target, missing: missing}
+	for i, rec := range records {
+		if missing(rec[target]) {
+			continue
+		}
+		if o.Missing == DropRow && anyMissing(rec, target, header, o.Ignore, missing) {
+			continue
+		}
+		p.rows = append(p.rows, rec)
+		p.lines = append(p.lines, lines[i])
+	}
+	if len(p.rows) == 0 {
+		return nil, fmt.Errorf("no row left after dropping missing values")
+	}
+
+	var cols []column
+	for j, name := range header {
+		if j == target || indexOf(o.Ignore, name) >= 0 {
+			continue
+		}
+		c, err := newColumn(name, j, p.rows, indexOf(o.Categorical, name) >= 0, o.Missing, missing)
+		if err != nil {
+			return nil, err
+		}
+		cols = append(cols, c)
+		p.columns = append(p.columns, c.names()...)
+	}
+	for _, rec := range p.rows {
+		x := make([]float64, 0, len(p.columns))
+		for _, c := range cols {
+			x = c.append(x, rec[c.index])
+		}
+		p.features = append(p.features, x)
+	}
+	return p, nil
+}
+
+func anyMissing(rec []string, target int, header, ignore []string, missing func(string) bool) bool {
+	for j, v := range rec {
+		if j != target && indexOf(ignore, header[j]) < 0 && missing(v) {
+			return true
+		}
+	}
+	return false
+}
+
+// column is a feature column of a CSV file: a number, or the categories
+// encoded one-hot.
+type column struct {
+	name       string
+	index      int
+	categories []string
+	fill       float64
+	missing    func(string) bool
+}
+
+func newColumn(name string, index int, rows [][]string, categorical bool, m Missing, missing func(string) bool) (column, error) {
+	c := column{name: name, index: index, missing: missing}
+	sum, n := 0.0, 0
+	for _, rec := range rows {
+		v := rec[index]
+		if categorical || missing(v) {
+			continue
+		}
+		f, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+		if err != nil {
+			categorical = true
+			break
+		}
+		sum += f
+		n++
+	}
+	if categorical {
+		c.categories = categories(rows, index, missing)
+		return c, nil
+	}
+	if m == FillMean && n > 0 {
+		c.fill = sum / float64(n)
+	}
+	return c, nil
+}
+
+func (c *column) names() []string {
+	if c.categories == nil {
+		return []string{c.name}
+	}
+	ret := make([]string, len(c.categories))
+	for i, v := range c.categories {
+		ret[i] = c.name + "=" + v
+	}
+	return ret
+}
+
+func (c *column) append(x []float64, v string) []float64 {
+	if c.categories != nil {
+		at := indexOf(c.categories, strings.TrimSpace(v))
+		for i := range c.categories {
+			if i == at {
+				x = append(x, 1)
+			} else {
+				x = append(x, 0)
+			}
+		}
+		return x
+	}
+	if c.missing(v) {
+		return append(x, c.fill)
+	}
+	f, _ := strconv.ParseFloat(strings.TrimSpace(v), 64)
+	return append(x, f)
+}
+
+// categories are the values of the column in numerical order if they are
+// all numbers, or else in lexical order.
+func categories(rows [][]string, index int, missing func(string) bool) []string {
+	seen := map[string]bool{}
+	var ret []string
+	numeric := true
+	for _, rec := range rows {
+		v := strings.TrimSpace(rec[index])
+		if missing(v) || seen[v] {
+			continue
+		}
+		seen[v] = true
+		ret = append(ret, v)
+		if _, err := strconv.ParseFloat(v, 64); err != nil {
+			numeric = false
+		}
+	}
+	sort.Slice(ret, func(i, j int) bool {
+		if numeric {
+			a, _ := strconv.ParseFloat(ret[i], 64)
+			b, _ := strconv.ParseFloat(ret[j], 64)
+			return a < b
+		}
+		return ret[i] < ret[j]
+	})
+	return ret
+}
+
+func indexOf(xs []string, x string) int {
+	for i, v := range xs {
+		if v == x {
+			return i
+		}
+	}
+	return -1
+}
+
+func (t *Table) Len() int {
+	return len(t.Features)
+}
+
+func (t *Table) Get(i int) ([]float64, int) {
+	return t.Features[i], t.Labels[i]
+}
+func (t *Table) Read(i int, x []float64) (int, error) {
+	copy(x, t.Features[i])
+	return t.Labels[i], nil
+}
+func (t *Table) LabelAt(i int) int {
+	return t.Labels[i]
+}
+
+func (t *Table) Shape() []int {
+	return []int{len(t.Columns)}
+}
+func (t *Table) Classes() int {
+	return len(t.Names)
+}
+
+// Batch is the features of the rows at with one row per example and their
+// one-hot labels.
+func (t *Table) Batch(at []int) (x, y *mat.Dense) {
+	x = batchOf(t.Features, len(t.Columns), at)
+	y = mat.NewDense(len(at), t.Classes(), nil)
+	for i, n := range at {
+		OneHot(y.RawRowView(i), t.Labels[n])
+	}
+	return x, y
+}
+
+func (t *RegressionTable) Len() int {
+	return len(t.Features)
+}
+
+// Batch is the features of the rows at with one row per example and a
+// column of their targets.
+func (t *RegressionTable) Batch(at []int) (x, y *mat.Dense) {
+	x = batchOf(t.Features, len(t.Columns), at)
+	y = mat.NewDense(len(at), 1, nil)
+	for i, n := range at {
+		y.Set(i, 0, t.Targets[n])
+	}
+	return x, y
+}
+
+func batchOf(features [][]float64, cols int, at []int) *mat.Dense {
+	x := mat.NewDense(len(at), cols, nil)
+	for i, n := range at {
+		x.SetRow(i, features[n])
+	}
+	return x
+}
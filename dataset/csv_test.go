@@ -0,0 +1,150 @@
+package dataset
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	mat "github.com/gonum/matrix/mat64"
+)
+
+const irisLike = `width, color, id, kind
+1.0, red, 1, b
+3.0, , 2, a
+, blue, 3, b
+2.0, red, 4, NA
+4.0, green, 5, c
+`
+
+func TestReadCSV(t *testing.T) {
+	cases := []struct {
+		title    string
+		options  CSVOptions
+		columns  []string
+		features [][]float64
+		names    []string
+		labels   []int
+	}{
+		{
+			title:   "fill mean",
+			options: CSVOptions{Target: "kind", Ignore: []string{"id"}},
+			columns: []string{"width", "color=blue", "color=green", "color=red"},
+			features: [][]float64{
+				{1, 0, 0, 1},
+				{3, 0, 0, 0},
+				{8.0 / 3, 1, 0, 0},
+				{4, 0, 1, 0},
+			},
+			names:  []string{"a", "b", "c"},
+			labels: []int{1, 0, 1, 2},
+		},
+		{
+			title:   "fill zero",
+			options: CSVOptions{Target: "kind", Ignore: []string{"id"}, Missing: FillZero},
+			columns: []string{"width", "color=blue", "color=green", "color=red"},
+			features: [][]float64{
+				{1, 0, 0, 1},
+				{3, 0, 0, 0},
+				{0, 1, 0, 0},
+				{4, 0, 1, 0},
+			},
+			names:  []string{"a", "b", "c"},
+			labels: []int{1, 0, 1, 2},
+		},
+		{
+			title:   "drop and categorical id",
+			options: CSVOptions{Target: "kind", Categorical: []string{"id"}, Missing: DropRow},
+			columns: []string{"width", "color=green", "color=red", "id=1", "id=5"},
+			features: [][]float64{
+				{1, 0, 1, 1, 0},
+				{4, 1, 0, 0, 1},
+			},
+			names:  []string{"b", "c"},
+			labels: []int{0, 1},
+		},
+	}
+	for _, c := range cases {
+		tab, err := ReadCSV(strings.NewReader(irisLike), &c.options)
+		if err != nil {
+			t.Fatalf("%s: %v", c.title, err)
+		}
+		if !reflect.DeepEqual(tab.Columns, c.columns) {
+			t.Fatalf("%s expect %v but got %v", c.title, c.columns, tab.Columns)
+		}
+		for i, x := range c.features {
+			if !mat.EqualApprox(mat.NewVector(len(x), x), mat.NewVector(len(x), tab.Features[i]), 1e-12) {
+				t.Fatalf("%s row %d expect %v but got %v", c.title, i, x, tab.Features[i])
+			}
+		}
+		if tab.Len() != len(c.features) {
+			t.Fatalf("%s expect %d rows but got %d", c.title, len(c.features), tab.Len())
+		}
+		if !reflect.DeepEqual(tab.Names, c.names) || !reflect.DeepEqual(tab.Labels, c.labels) {
+			t.Fatalf("%s expect %v %v but got %v %v", c.title, c.names, c.labels, tab.Names, tab.Labels)
+		}
+	}
+}
+
+func TestReadCSVRegression(t *testing.T) {
+	data := "3;1;10\n1;2;9.5\n2;?;7\n"
+	tab, err := ReadRegressionCSV(strings.NewReader(data), &CSVOptions{Target: "0", NoHeader: true, Comma: ';'})
+	if err != nil {
+		t.Fatal(err)
+	}
+	x, y := tab.Batch([]int{2, 0})
+	expectX := mat.NewDense(2, 2, []float64{
+		1.5, 7,
+		1, 10,
+	})
+	expectY := mat.NewDense(2, 1, []float64{2, 3})
+	if !mat.Equal(x, expectX) || !mat.Equal(y, expectY) {
+		t.Fatalf("expect %v %v but got %v %v", mat.Formatted(expectX), mat.Formatted(expectY), mat.Formatted(x), mat.Formatted(y))
+	}
+}
+
+func TestReadCSVClassBatch(t *testing.T) {
+	data := "x,label\n0.5,10\n1,2\n2,10\n"
+	tab, err := ReadCSV(strings.NewReader(data), &CSVOptions{Target: "label"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// labels sorted as numbers
+	if !reflect.DeepEqual(tab.Names, []string{"2", "10"}) {
+		t.Fatalf("expect [2 10] but got %v", tab.Names)
+	}
+	_, y := tab.Batch([]int{0, 1})
+	expect := mat.NewDense(2, 2, []float64{
+		0, 1,
+		1, 0,
+	})
+	if !mat.Equal(y, expect) {
+		t.Fatalf("expect %v but got %v", mat.Formatted(expect), mat.Formatted(y))
+	}
+}
+
+func TestReadCSVError(t *testing.T) {
+	cases := []struct {
+		title   string
+		data    string
+		options CSVOptions
+		err     string
+	}{
+		{"target", "a,b\n1,2\n", CSVOptions{Target: "c"}, `no target column "c"`},
+		{"ignore", "a,b\n1,2\n", CSVOptions{Target: "a", Ignore: []string{"x"}}, `no column "x"`},
+		{"all dropped", "a,b\n,1\n", CSVOptions{Target: "b", Missing: DropRow}, "no row left"},
+		{"ragged", "a,b\n1\n", CSVOptions{Target: "b"}, "wrong number of fields"},
+	}
+	for _, c := range cases {
+		_, err := ReadCSV(strings.NewReader(c.data), &c.options)
+		if err == nil || !strings.Contains(err.Error(), c.err) {
+			t.Fatalf("%s expect %q but got %v", c.title, c.err, err)
+		}
+	}
+
+	// the line in the file, counting the header and the rows dropped
+	data := "a,b\n1,\n2,\"3\n\"\n3,x\n"
+	_, err := ReadRegressionCSV(strings.NewReader(data), &CSVOptions{Target: "b"})
+	if expect := `line 5, column "b"`; err == nil || !strings.Contains(err.Error(), expect) {
+		t.Fatalf("expect %q but got %v", expect, err)
+	}
+}
@@ -0,0 +1,148 @@
+/*
+Package dataset abstracts the examples a network learns from, so that the
+training code does not depend on MNIST.
+*/
+package dataset
+
+import (
+	"fmt"
+	"sync"
+)
+
+// Dataset is a set of labelled examples. Get returns the features of the
+// i-th example and its label in [0, Classes()); the slice may be reused by
+// the next call. Shape is the shape of the features, such as
+// (channels, rows, cols) for images.
+type Dataset interface {
+	Len() int
+	Get(i int) ([]float64, int)
+	Shape() []int
+	Classes() int
+}
+
+// Labeler is a Dataset able to read a label without its features.
+type Labeler interface {
+	LabelAt(i int) int
+}
+
+// Reader is a Dataset able to read the features of the i-th example into x
+// from several goroutines at once.
+type Reader interface {
+	Read(i int, x []float64) (int, error)
+}
+
+// NewReader is d itself if d is a Reader, or otherwise d reading one example
+// at a time.
+func NewReader(d Dataset) Reader {
+	if r, ok := d.(Reader); ok {
+		return r
+	}
+	return &lockedReader{d: d}
+}
+
+type lockedReader struct {
+	mu sync.Mutex
+	d  Dataset
+}
+
+func (r *lockedReader) Read(i int, x []float64) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	f, label := r.d.Get(i)
+	copy(x, f)
+	return label, nil
+}
+
+// Label is the label of the i-th example of d.
+func Label(d Dataset, i int) int {
+	if l, ok := d.(Labeler); ok {
+		return l.LabelAt(i)
+	}
+	_, label := d.Get(i)
+	return label
+}
+
+// Size is the number of features of an example of d.
+func Size(d Dataset) int {
+	ret := 1
+	for _, x := range d.Shape() {
+		ret *= x
+	}
+	return ret
+}
+
+// ImageSize is the number of rows and columns of the examples of d, taken
+// from the last two dimensions of its shape. A flat shape is one row.
+func ImageSize(d Dataset) (rows, cols int) {
+	s := d.Shape()
+	switch len(s) {
+	case 0:
+		panic("shape should not be empty")
+	case 1:
+		return 1, s[0]
+	}
+	return s[len(s)-2], s[len(s)-1]
+}
+
+// OneHot writes label as a one-hot vector to t.
+func OneHot(t []float64, label int) {
+	if label < 0 || label >= len(t) {
+		panic(fmt.Sprintf("label %d should be in [0, %d)", label, len(t)))
+	}
+	for i := range t {
+		t[i] = 0
+	}
+	t[label] = 1
+}
+
+// Memory is a Dataset held in memory.
+type Memory struct {
+	Features   [][]float64
+	Labels     []int
+	Dims       []int
+	NumClasses int
+}
+
+var (
+	_ Dataset = (*Memory)(nil)
+	_ Labeler = (*Memory)(nil)
+	_ Reader  = (*Memory)(nil)
+)
+
+// NewMemory is a Memory of flat features, counting the classes from the
+// labels.
+func NewMemory(features [][]float64, labels []int) *Memory {
+	if len(features) != len(labels) {
+		panic(fmt.Sprintf("%d features but %d labels", len(features), len(labels)))
+	}
+	m := &Memory{Features: features, Labels: labels}
+	if len(features) > 0 {
+		m.Dims = []int{len(features[0])}
+	}
+	for _, l := range labels {
+		if l >= m.NumClasses {
+			m.NumClasses = l + 1
+		}
+	}
+	return m
+}
+
+func (m *Memory) Len() int {
+	return len(m.Labels)
+}
+func (m *Memory) Get(i int) ([]float64, int) {
+	return m.Features[i], m.Labels[i]
+}
+func (m *Memory) Read(i int, x []float64) (int, error) {
+	copy(x, m.Features[i])
+	return m.Labels[i], nil
+}
+func (m *Memory) LabelAt(i int) int {
+	return m.Labels[i]
+}
+func (m *Memory) Shape() []int {
+	return m.Dims
+}
+func (m *Memory) Classes() int {
+	return m.NumClasses
+}
@@ -0,0 +1,197 @@
+package dataset
+
+import (
+	"fmt"
+	"image"
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+	"io/ioutil"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ImageDir is a Dataset of the image files under Root, with one
+// subdirectory per class:
+//
+//	root/cat/001.png
+//	root/cat/more/002.jpg
+//	root/dog/001.gif
+//
+// Classes are numbered in the order of the names of the subdirectories.
+// Each image is decoded when read, resized to Rows x Cols and converted to
+// grayscale for 1 channel or to RGB for 3 channels, with values in [0, 1].
+type ImageDir struct {
+	Root     string
+	Names    []string
+	Paths    []string
+	Labels   []int
+	Channels int
+	Rows     int
+	Cols     int
+
+	x []float64
+}
+
+var (
+	_ Dataset = (*ImageDir)(nil)
+	_ Labeler = (*ImageDir)(nil)
+	_ Reader  = (*ImageDir)(nil)
+)
+
+// NewImageDir lists the PNG, JPEG and GIF files under root.
+func NewImageDir(root string, channels, rows, cols int) (*ImageDir, error) {
+	if channels != 1 && channels != 3 {
+		return nil, fmt.Errorf("channels should be 1 or 3 but got %d", channels)
+	}
+	if rows <= 0 || cols <= 0 {
+		return nil, fmt.Errorf("image size %dx%d should be positive", rows, cols)
+	}
+	infos, err := ioutil.ReadDir(root)
+	if err != nil {
+		return nil, err
+	}
+	d := &ImageDir{Root: root, Channels: channels, Rows: rows, Cols: cols}
+	for _, info := range infos {
+		if !info.IsDir() {
+			continue
+		}
+		label := len(d.Names)
+		d.Names = append(d.Names, info.Name())
+		err := filepath.Walk(filepath.Join(root, info.Name()), func(path string, fi os.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+			if !fi.IsDir() && isImage(path) {
+				d.Paths = append(d.Paths, path)
+				d.Labels = append(d.Labels, label)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	if len(d.Names) == 0 {
+		return nil, fmt.Errorf("%s has no class directory", root)
+	}
+	return d, nil
+}
+
+func isImage(path string) bool {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".png", ".jpg", ".jpeg", ".gif":
+		return true
+	}
+	return false
+}
+
+func (d *ImageDir) Len() int {
+	return len(d.Paths)
+}
+
+// Get panics if the image cannot be read, as Read would return the error.
+func (d *ImageDir) Get(i int) ([]float64, int) {
+	if d.x == nil {
+		d.x = make([]float64, d.Channels*d.Rows*d.Cols)
+	}
+	label, err := d.Read(i, d.x)
+	if err != nil {
+		panic(err)
+	}
+	return d.x, label
+}
+
+func (d *ImageDir) Read(i int, x []float64) (int, error) {
+	f, err := os.Open(d.Paths[i])
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+	img, _, err := image.Decode(f)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %v", d.Paths[i], err)
+	}
+	if img.Bounds().Empty() {
+		return 0, fmt.Errorf("%s: empty image", d.Paths[i])
+	}
+	Resize(img, d.Channels, d.Rows, d.Cols, x)
+	return d.Labels[i], nil
+}
+
+func (d *ImageDir) LabelAt(i int) int {
+	return d.Labels[i]
+}
+func (d *ImageDir) Shape() []int {
+	return []int{d.Channels, d.Rows, d.Cols}
+}
+func (d *ImageDir) Classes() int {
+	return len(d.Names)
+}
+
+// ClassName is the name of the directory of label.
+func (d *ImageDir) ClassName(label int) string {
+	return d.Names[label]
+}
+
+// Resize writes img resized to rows x cols by bilinear interpolation to x,
+// channel by channel: the luma for 1 channel, or red, green and blue for 3
+// channels, in [0, 1]. Transparent pixels are taken as black.
+func Resize(img image.Image, channels, rows, cols int, x []float64) {
+	if len(x) < channels*rows*cols {
+		panic(fmt.Sprintf("expect %d values but got %d", channels*rows*cols, len(x)))
+	}
+	b := img.Bounds()
+	w, h := b.Dx(), b.Dy()
+
+	// planes : the channels of img in [0, 1]
+	planes := make([][]float64, channels)
+	for ch := range planes {
+		planes[ch] = make([]float64, w*h)
+	}
+	for y := 0; y < h; y++ {
+		for px := 0; px < w; px++ {
+			r, g, bl, _ := img.At(b.Min.X+px, b.Min.Y+y).RGBA()
+			rf, gf, bf := float64(r)/0xffff, float64(g)/0xffff, float64(bl)/0xffff
+			i := y*w + px
+			if channels == 1 {
+				planes[0][i] = 0.299*rf + 0.587*gf + 0.114*bf
+			} else {
+				planes[0][i], planes[1][i], planes[2][i] = rf, gf, bf
+			}
+		}
+	}
+
+	at := func(p []float64, y, x int) float64 {
+		y = clamp(y, h-1)
+		x = clamp(x, w-1)
+		return p[y*w+x]
+	}
+	for ch, p := range planes {
+		for r := 0; r < rows; r++ {
+			sy := (float64(r)+0.5)*float64(h)/float64(rows) - 0.5
+			y0 := math.Floor(sy)
+			fy := sy - y0
+			for c := 0; c < cols; c++ {
+				sx := (float64(c)+0.5)*float64(w)/float64(cols) - 0.5
+				x0 := math.Floor(sx)
+				fx := sx - x0
+				y, xx := int(y0), int(x0)
+				x[(ch*rows+r)*cols+c] = at(p, y, xx)*(1-fy)*(1-fx) + at(p, y, xx+1)*(1-fy)*fx +
+					at(p, y+1, xx)*fy*(1-fx) + at(p, y+1, xx+1)*fy*fx
+			}
+		}
+	}
+}
+
+func clamp(i, max int) int {
+	if i < 0 {
+		return 0
+	}
+	if i > max {
+		return max
+	}
+	return i
+}
@@ -0,0 +1,144 @@
+package dataset
+
+import (
+	"image"
+	"image/color"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"io/ioutil"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// writeImage writes a w x h image of c encoded by the extension of path.
+func writeImage(t *testing.T, path string, w, h int, c color.Color) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			img.Set(x, y, c)
+		}
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	switch filepath.Ext(path) {
+	case ".png":
+		err = png.Encode(f, img)
+	case ".jpg":
+		err = jpeg.Encode(f, img, &jpeg.Options{Quality: 100})
+	case ".gif":
+		err = gif.Encode(f, img, nil)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func imageDir(t *testing.T) string {
+	root, err := ioutil.TempDir("", "imagedir")
+	if err != nil {
+		t.Fatal(err)
+	}
+	writeImage(t, filepath.Join(root, "red", "a.png"), 4, 2, color.RGBA{255, 0, 0, 255})
+	writeImage(t, filepath.Join(root, "red", "sub", "b.jpg"), 8, 8, color.RGBA{255, 0, 0, 255})
+	writeImage(t, filepath.Join(root, "white", "c.gif"), 3, 3, color.White)
+	writeImage(t, filepath.Join(root, "black", "d.png"), 1, 1, color.Black)
+	ioutil.WriteFile(filepath.Join(root, "white", "README"), []byte("not an image"), 0644)
+	ioutil.WriteFile(filepath.Join(root, "notes.txt"), []byte("not a class"), 0644)
+	return root
+}
+
+func TestImageDir(t *testing.T) {
+	root := imageDir(t)
+	defer os.RemoveAll(root)
+
+	cases := []struct {
+		title    string
+		channels int
+		pixels   [][]float64
+	}{
+		{title: "gray", channels: 1, pixels: [][]float64{{0}, {0.299}, {0.299}, {1}}},
+		{title: "rgb", channels: 3, pixels: [][]float64{{0, 0, 0}, {1, 0, 0}, {1, 0, 0}, {1, 1, 1}}},
+	}
+	for _, c := range cases {
+		d, err := NewImageDir(root, c.channels, 2, 3)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if d.Len() != 4 || d.Classes() != 3 {
+			t.Fatalf("%s expect 4 images of 3 classes but got %d of %d", c.title, d.Len(), d.Classes())
+		}
+		if d.ClassName(1) != "red" {
+			t.Fatalf("%s expect red but got %s", c.title, d.ClassName(1))
+		}
+		if s := d.Shape(); s[0] != c.channels || s[1] != 2 || s[2] != 3 {
+			t.Fatalf("%s expect (%d, 2, 3) but got %v", c.title, c.channels, s)
+		}
+		for i, labels := 0, []int{0, 1, 1, 2}; i < d.Len(); i++ {
+			x, label := d.Get(i)
+			if label != labels[i] || Label(d, i) != labels[i] {
+				t.Fatalf("%s %s expect %d but got %d", c.title, d.Paths[i], labels[i], label)
+			}
+			if len(x) != c.channels*6 {
+				t.Fatalf("%s expect %d values but got %d", c.title, c.channels*6, len(x))
+			}
+			for j, v := range x {
+				// jpeg is lossy
+				if expect := c.pixels[i][j/6]; math.Abs(v-expect) > 0.02 {
+					t.Fatalf("%s %s expect %v but got %v", c.title, d.Paths[i], expect, x)
+				}
+			}
+		}
+	}
+}
+
+func TestImageDirError(t *testing.T) {
+	root := imageDir(t)
+	defer os.RemoveAll(root)
+
+	if _, err := NewImageDir(root, 2, 28, 28); err == nil {
+		t.Fatalf("2 channels should be an error")
+	}
+	if _, err := NewImageDir(filepath.Join(root, "black"), 1, 28, 28); err == nil {
+		t.Fatalf("directory without classes should be an error")
+	}
+
+	d, err := NewImageDir(root, 1, 28, 28)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ioutil.WriteFile(d.Paths[0], []byte("broken"), 0644)
+	if _, err := d.Read(0, make([]float64, 28*28)); err == nil {
+		t.Fatalf("broken image should be an error")
+	}
+}
+
+func TestResize(t *testing.T) {
+	img := image.NewGray(image.Rect(0, 0, 2, 2))
+	img.Pix = []uint8{0, 255, 255, 0}
+	cases := []struct {
+		rows, cols int
+		expect     []float64
+	}{
+		{rows: 1, cols: 1, expect: []float64{0.5}},
+		{rows: 2, cols: 2, expect: []float64{0, 1, 1, 0}},
+		{rows: 2, cols: 4, expect: []float64{0, 0.25, 0.75, 1, 1, 0.75, 0.25, 0}},
+	}
+	for _, c := range cases {
+		x := make([]float64, c.rows*c.cols)
+		Resize(img, 1, c.rows, c.cols, x)
+		for i := range x {
+			if math.Abs(x[i]-c.expect[i]) > 1e-9 {
+				t.Fatalf("%dx%d expect %v but got %v", c.rows, c.cols, c.expect, x)
+			}
+		}
+	}
+}
@@ -0,0 +1,95 @@
+package dataset
+
+import (
+	"fmt"
+	"math/rand"
+)
+
+// Seq is start, start+1, ..., start+n-1.
+func Seq(start, n int) []int {
+	ret := make([]int, n)
+	for i := range ret {
+		ret[i] = start + i
+	}
+	return ret
+}
+
+// Sampler gives the index of the examples of an epoch by batches of
+// BatchSize. With Shuffle, each epoch is permuted in an order determined by
+// Seed and the epoch. With DropLast, a last batch smaller than BatchSize is
+// dropped.
+type Sampler struct {
+	Index     []int
+	BatchSize int
+	Shuffle   bool
+	Seed      int64
+	DropLast  bool
+
+	order []int
+	pos   int
+}
+
+// NewSampler gives 0 to n-1 in order.
+func NewSampler(n, batchSize int) *Sampler {
+	return NewIndexSampler(Seq(0, n), batchSize)
+}
+
+// NewIndexSampler gives index in order.
+func NewIndexSampler(index []int, batchSize int) *Sampler {
+	if batchSize <= 0 {
+		panic(fmt.Sprintf("batch size should be positive but got %d", batchSize))
+	}
+	s := &Sampler{Index: index, BatchSize: batchSize}
+	s.Reset(0)
+	return s
+}
+
+// NewShuffledSampler gives 0 to n-1 shuffled every epoch.
+func NewShuffledSampler(n, batchSize int, seed int64) *Sampler {
+	return NewShuffledIndexSampler(Seq(0, n), batchSize, seed)
+}
+
+// NewShuffledIndexSampler gives index shuffled every epoch.
+func NewShuffledIndexSampler(index []int, batchSize int, seed int64) *Sampler {
+	s := NewIndexSampler(index, batchSize)
+	s.Shuffle = true
+	s.Seed = seed
+	s.Reset(0)
+	return s
+}
+
+// Len is the number of batches of an epoch.
+func (s *Sampler) Len() int {
+	if s.DropLast {
+		return len(s.Index) / s.BatchSize
+	}
+	return (len(s.Index) + s.BatchSize - 1) / s.BatchSize
+}
+
+// Reset starts the epoch over.
+func (s *Sampler) Reset(epoch int) {
+	s.pos = 0
+	if !s.Shuffle {
+		s.order = s.Index
+		return
+	}
+	r := rand.New(rand.NewSource(s.Seed + int64(epoch)))
+	s.order = make([]int, len(s.Index))
+	for i, j := range r.Perm(len(s.Index)) {
+		s.order[i] = s.Index[j]
+	}
+}
+
+// Next is the index of the next batch, or false at the end of the epoch.
+func (s *Sampler) Next() ([]int, bool) {
+	n := len(s.order) - s.pos
+	if n > s.BatchSize {
+		n = s.BatchSize
+	}
+	if n <= 0 || (s.DropLast && n < s.BatchSize) {
+		return nil, false
+	}
+	at := s.order[s.pos : s.pos+n]
+	s.pos += n
+	return at, true
+}
@@ -0,0 +1,71 @@
+package dataset
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestSampler(t *testing.T) {
+	cases := []struct {
+		title    string
+		sampler  *Sampler
+		dropLast bool
+		sizes    []int
+	}{
+		{title: "in order", sampler: NewSampler(10, 4), sizes: []int{4, 4, 2}},
+		{title: "drop last", sampler: NewSampler(10, 4), dropLast: true, sizes: []int{4, 4}},
+		{title: "shuffled", sampler: NewShuffledSampler(10, 5, 1), sizes: []int{5, 5}},
+		{title: "index", sampler: NewIndexSampler([]int{3, 1, 4}, 2), sizes: []int{2, 1}},
+	}
+	for _, c := range cases {
+		s := c.sampler
+		s.DropLast = c.dropLast
+		if s.Len() != len(c.sizes) {
+			t.Fatalf("%s expect %d batches but got %d", c.title, len(c.sizes), s.Len())
+		}
+		for epoch := 0; epoch < 2; epoch++ {
+			s.Reset(epoch)
+			seen := map[int]int{}
+			var sizes []int
+			for at, ok := s.Next(); ok; at, ok = s.Next() {
+				sizes = append(sizes, len(at))
+				for _, i := range at {
+					seen[i]++
+				}
+			}
+			if fmt.Sprint(sizes) != fmt.Sprint(c.sizes) {
+				t.Fatalf("%s expect %v but got %v", c.title, c.sizes, sizes)
+			}
+			for i, n := range seen {
+				if n != 1 {
+					t.Fatalf("%s expect %d once but got %d times", c.title, i, n)
+				}
+			}
+			if !c.dropLast && len(seen) != len(s.Index) {
+				t.Fatalf("%s expect %d examples but got %d", c.title, len(s.Index), len(seen))
+			}
+		}
+	}
+}
+
+func TestShuffledSamplerSeed(t *testing.T) {
+	epoch := func(s *Sampler, e int) []int {
+		s.Reset(e)
+		var ret []int
+		for at, ok := s.Next(); ok; at, ok = s.Next() {
+			ret = append(ret, at...)
+		}
+		return ret
+	}
+	a := NewShuffledSampler(100, 10, 42)
+	b := NewShuffledSampler(100, 10, 42)
+	if fmt.Sprint(epoch(a, 3)) != fmt.Sprint(epoch(b, 3)) {
+		t.Fatalf("same seed and epoch should give the same order")
+	}
+	if fmt.Sprint(epoch(a, 0)) == fmt.Sprint(epoch(a, 1)) {
+		t.Fatalf("each epoch should be shuffled differently")
+	}
+	if fmt.Sprint(epoch(a, 0)) == fmt.Sprint(Seq(0, 100)) {
+		t.Fatalf("should be shuffled")
+	}
+}
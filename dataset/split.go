@@ -0,0 +1,53 @@
+package dataset
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"sort"
+)
+
+// Split divides 0 to n-1 into a fraction ratio for validation and the rest
+// for training, in a way determined by seed. Both are sorted.
+func Split(n int, ratio float64, seed int64) (train, valid []int) {
+	return SplitByLabel(n, func(int) int { return 0 }, ratio, seed)
+}
+
+// StratifiedSplit is Split taking the same fraction of each class of d.
+func StratifiedSplit(d Dataset, ratio float64, seed int64) (train, valid []int) {
+	return SplitByLabel(d.Len(), func(i int) int { return Label(d, i) }, ratio, seed)
+}
+
+// SplitByLabel is Split taking the same fraction of each class, label(i)
+// being the class of i.
+func SplitByLabel(n int, label func(i int) int, ratio float64, seed int64) (train, valid []int) {
+	if ratio < 0 || ratio > 1 {
+		panic(fmt.Sprintf("ratio should be in [0, 1] but got %g", ratio))
+	}
+	classes := map[int][]int{}
+	var keys []int
+	for i := 0; i < n; i++ {
+		l := label(i)
+		if _, ok := classes[l]; !ok {
+			keys = append(keys, l)
+		}
+		classes[l] = append(classes[l], i)
+	}
+	sort.Ints(keys)
+
+	r := rand.New(rand.NewSource(seed))
+	for _, l := range keys {
+		index := classes[l]
+		k := int(math.Floor(float64(len(index))*ratio + 0.5))
+		for i, j := range r.Perm(len(index)) {
+			if i < k {
+				valid = append(valid, index[j])
+			} else {
+				train = append(train, index[j])
+			}
+		}
+	}
+	sort.Ints(train)
+	sort.Ints(valid)
+	return train, valid
+}
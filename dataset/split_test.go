@@ -0,0 +1,96 @@
+package dataset
+
+import (
+	"fmt"
+	"sort"
+	"testing"
+)
+
+func TestSplit(t *testing.T) {
+	cases := []struct {
+		title string
+		n     int
+		ratio float64
+		valid int
+	}{
+		{title: "10%", n: 100, ratio: 0.1, valid: 10},
+		{title: "round", n: 15, ratio: 0.1, valid: 2},
+		{title: "none", n: 10, ratio: 0, valid: 0},
+		{title: "all", n: 10, ratio: 1, valid: 10},
+	}
+	for _, c := range cases {
+		train, valid := Split(c.n, c.ratio, 1)
+		if len(valid) != c.valid || len(train) != c.n-c.valid {
+			t.Fatalf("%s expect %d/%d but got %d/%d", c.title, c.n-c.valid, c.valid, len(train), len(valid))
+		}
+		all := append(append([]int{}, train...), valid...)
+		sort.Ints(all)
+		for i, x := range all {
+			if x != i {
+				t.Fatalf("%s expect a partition but got %v and %v", c.title, train, valid)
+			}
+		}
+		if !sort.IntsAreSorted(train) || !sort.IntsAreSorted(valid) {
+			t.Fatalf("%s expect sorted index", c.title)
+		}
+	}
+
+	a, _ := Split(100, 0.2, 7)
+	b, _ := Split(100, 0.2, 7)
+	c, _ := Split(100, 0.2, 8)
+	if fmt.Sprint(a) != fmt.Sprint(b) {
+		t.Fatalf("same seed should give the same split")
+	}
+	if fmt.Sprint(a) == fmt.Sprint(c) {
+		t.Fatalf("another seed should give another split")
+	}
+}
+
+func TestSplitByLabel(t *testing.T) {
+	// 60 of class 0, 30 of class 1 and 10 of class 2
+	label := func(i int) int {
+		switch {
+		case i < 60:
+			return 0
+		case i < 90:
+			return 1
+		}
+		return 2
+	}
+	_, valid := SplitByLabel(100, label, 0.1, 1)
+	count := map[int]int{}
+	for _, i := range valid {
+		count[label(i)]++
+	}
+	expect := map[int]int{0: 6, 1: 3, 2: 1}
+	if fmt.Sprint(count) != fmt.Sprint(expect) {
+		t.Fatalf("expect %v but got %v", expect, count)
+	}
+}
+
+func TestStratifiedSplit(t *testing.T) {
+	// 60 of class 0, 30 of class 1 and 10 of class 2
+	labels := make([]int, 100)
+	features := make([][]float64, 100)
+	for i := range labels {
+		switch {
+		case i < 60:
+			labels[i] = 0
+		case i < 90:
+			labels[i] = 1
+		default:
+			labels[i] = 2
+		}
+		features[i] = []float64{float64(i)}
+	}
+	d := NewMemory(features, labels)
+	_, valid := StratifiedSplit(d, 0.1, 1)
+	count := map[int]int{}
+	for _, i := range valid {
+		count[labels[i]]++
+	}
+	expect := map[int]int{0: 6, 1: 3, 2: 1}
+	if fmt.Sprint(count) != fmt.Sprint(expect) {
+		t.Fatalf("expect %v but got %v", expect, count)
+	}
+}
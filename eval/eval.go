@@ -0,0 +1,146 @@
+/*
+Package eval measures a classifier beyond its accuracy: the confusion
+matrix, the precision, recall and F1 of each class and their averages, and
+the top-k accuracies.
+*/
+package eval
+
+import (
+	"fmt"
+
+	mat "github.com/gonum/matrix/mat64"
+
+	"github.com/ajiyoshi/gocnn/batch"
+	"github.com/ajiyoshi/gocnn/matrix"
+	"github.com/ajiyoshi/gocnn/train"
+)
+
+// Predictor gives the scores of the classes for a batch of inputs x with
+// one example per row.
+type Predictor interface {
+	Predict(x mat.Matrix) mat.Matrix
+}
+
+var (
+	_ Predictor = (*batch.NeuralNet)(nil)
+	_ Predictor = (*train.CNN)(nil)
+)
+
+// Evaluation counts the predictions of a classifier of Classes() classes.
+type Evaluation struct {
+	// Names are the names of the classes, their numbers if nil.
+	Names []string
+	// Confusion[i][j] is the number of examples of class i predicted as
+	// class j.
+	Confusion [][]int
+	// TopK are the k of the top-k accuracies to count, Hits the number of
+	// examples whose class is among the k best scores out of the Scored
+	// examples counted by AddScores.
+	TopK   []int
+	Hits   []int
+	Scored int
+}
+
+func NewEvaluation(classes int, topK ...int) *Evaluation {
+	e := &Evaluation{
+		Confusion: make([][]int, classes),
+		TopK:      topK,
+		Hits:      make([]int, len(topK)),
+	}
+	for i := range e.Confusion {
+		e.Confusion[i] = make([]int, classes)
+	}
+	return e
+}
+
+// Evaluate counts the predictions of p over one epoch of l, failing with
+// the error of l.
+func Evaluate(p Predictor, l train.Loader, classes int, topK ...int) (*Evaluation, error) {
+	e := NewEvaluation(classes, topK...)
+	l.Reset(0)
+	for x, t, ok := l.Next(); ok; x, t, ok = l.Next() {
+		e.AddScores(p.Predict(x), t)
+	}
+	if err := l.Err(); err != nil {
+		return nil, err
+	}
+	return e, nil
+}
+
+func (e *Evaluation) Classes() int {
+	return len(e.Confusion)
+}
+
+// Total is the number of examples counted.
+func (e *Evaluation) Total() int {
+	n := 0
+	for _, row := range e.Confusion {
+		for _, v := range row {
+			n += v
+		}
+	}
+	return n
+}
+
+// Add counts an example of class label predicted as predicted. Having no
+// scores, it is not counted for the top-k accuracies.
+func (e *Evaluation) Add(label, predicted int) {
+	e.check(label)
+	e.check(predicted)
+	e.Confusion[label][predicted]++
+}
+
+// AddScores counts the examples of the rows of the scores y and the one-hot
+// labels t, which should have a class in every row. The predicted class of
+// a row is its best score, the first one among equal scores.
+func (e *Evaluation) AddScores(y, t mat.Matrix) {
+	r, c := y.Dims()
+	if tr, tc := t.Dims(); r != tr || c != tc || c != e.Classes() {
+		panic(fmt.Sprintf("expect scores and labels of %d classes but got %dx%d and %dx%d", e.Classes(), r, c, tr, tc))
+	}
+	scores := make([]float64, c)
+	labels := make([]float64, c)
+	for i := 0; i < r; i++ {
+		mat.Row(scores, i, y)
+		mat.Row(labels, i, t)
+		label := matrix.Argmax(labels)
+		if labels[label] <= 0 {
+			panic(fmt.Sprintf("row %d of the labels has no class", i))
+		}
+		e.Confusion[label][matrix.Argmax(scores)]++
+		e.Scored++
+		rank := Rank(scores, label)
+		for j, k := range e.TopK {
+			if rank < k {
+				e.Hits[j]++
+			}
+		}
+	}
+}
+
+// Rank is the place of the score of label among scores from 0, ties going
+// to the lower class, so that the class of rank 0 is the Argmax.
+func Rank(scores []float64, label int) int {
+	s := scores[label]
+	rank := 0
+	for i, v := range scores {
+		if v > s || (v == s && i < label) {
+			rank++
+		}
+	}
+	return rank
+}
+
+func (e *Evaluation) check(label int) {
+	if label < 0 || label >= e.Classes() {
+		panic(fmt.Sprintf("class %d out of [0, %d)", label, e.Classes()))
+	}
+}
+
+// Name is the name of the class label.
+func (e *Evaluation) Name(label int) string {
+	if e.Names != nil && label < len(e.Names) {
+		return e.Names[label]
+	}
+	return fmt.Sprint(label)
+}
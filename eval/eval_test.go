@@ -0,0 +1,215 @@
+package eval
+
+import (
+	"bytes"
+	"encoding/json"
+	"math"
+	"strings"
+	"testing"
+
+	mat "github.com/gonum/matrix/mat64"
+)
+
+// toy are 6 examples of 3 classes: 2 right of class 0, 1 of class 0 taken
+// as 1, 1 right of class 1, 1 of class 1 taken as 2 and 1 of class 2 taken
+// as 0. The true class is the second best of every wrong example but the
+// last, where it is the third.
+func toy() (y, t *mat.Dense) {
+	y = mat.NewDense(6, 3, []float64{
+		0.8, 0.1, 0.1,
+		0.5, 0.3, 0.2,
+		0.4, 0.5, 0.1,
+		0.1, 0.7, 0.2,
+		0.1, 0.4, 0.5,
+		0.5, 0.4, 0.1,
+	})
+	t = mat.NewDense(6, 3, []float64{
+		1, 0, 0,
+		1, 0, 0,
+		1, 0, 0,
+		0, 1, 0,
+		0, 1, 0,
+		0, 0, 1,
+	})
+	return y, t
+}
+
+func TestEvaluation(t *testing.T) {
+	y, lt := toy()
+	e := NewEvaluation(3, 1, 2, 3)
+	e.Names = []string{"a", "b", "c"}
+	e.AddScores(y, lt)
+
+	confusion := [][]int{{2, 1, 0}, {0, 1, 1}, {1, 0, 0}}
+	for i, row := range confusion {
+		for j, v := range row {
+			if e.Confusion[i][j] != v {
+				t.Fatalf("confusion (%d, %d) expect %v but got %v", i, j, v, e.Confusion[i][j])
+			}
+		}
+	}
+
+	r := e.Report()
+	cases := []struct {
+		title  string
+		expect float64
+		actual float64
+	}{
+		{"accuracy", 0.5, r.Accuracy},
+		{"top-1", 0.5, r.TopK[0].Accuracy},
+		{"top-2", 5.0 / 6, r.TopK[1].Accuracy},
+		{"top-3", 1, r.TopK[2].Accuracy},
+		{"precision a", 2.0 / 3, r.Classes[0].Precision},
+		{"recall a", 2.0 / 3, r.Classes[0].Recall},
+		{"f1 a", 2.0 / 3, r.Classes[0].F1},
+		{"precision b", 0.5, r.Classes[1].Precision},
+		{"recall b", 0.5, r.Classes[1].Recall},
+		{"f1 c", 0, r.Classes[2].F1},
+		{"macro precision", (2.0/3 + 0.5) / 3, r.Macro.Precision},
+		{"macro f1", (2.0/3 + 0.5) / 3, r.Macro.F1},
+		{"micro precision", 0.5, r.Micro.Precision},
+		{"micro recall", 0.5, r.Micro.Recall},
+		{"micro f1", 0.5, r.Micro.F1},
+	}
+	for _, c := range cases {
+		if math.Abs(c.expect-c.actual) > 1e-12 {
+			t.Fatalf("%s expect %v but got %v", c.title, c.expect, c.actual)
+		}
+	}
+	if r.Total != 6 || r.Classes[0].Support != 3 || r.Classes[2].Name != "c" {
+		t.Fatalf("expect 6 examples, 3 of class a but got %d, %d", r.Total, r.Classes[0].Support)
+	}
+}
+
+func TestRank(t *testing.T) {
+	cases := []struct {
+		scores []float64
+		label  int
+		expect int
+	}{
+		{[]float64{0.1, 0.7, 0.2}, 1, 0},
+		{[]float64{0.1, 0.7, 0.2}, 0, 2},
+		// ties go to the lower class like Argmax
+		{[]float64{0.5, 0.5, 0}, 0, 0},
+		{[]float64{0.5, 0.5, 0}, 1, 1},
+	}
+	for _, c := range cases {
+		if actual := Rank(c.scores, c.label); actual != c.expect {
+			t.Fatalf("Rank(%v, %d) expect %v but got %v", c.scores, c.label, c.expect, actual)
+		}
+	}
+}
+
+func TestAdd(t *testing.T) {
+	e := NewEvaluation(2, 1)
+	e.Add(0, 0)
+	e.Add(1, 0)
+	r := e.Report()
+	if r.Accuracy != 0.5 || r.TopK[0].Accuracy != 0 || r.Classes[1].Recall != 0 {
+		t.Fatalf("expect accuracy 0.5 without top-k but got %v, %v", r.Accuracy, r.TopK)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Fatalf("a class out of range should panic")
+		}
+	}()
+	e.Add(2, 0)
+}
+
+func TestAddScoresNoLabel(t *testing.T) {
+	e := NewEvaluation(2)
+	defer func() {
+		if recover() == nil {
+			t.Fatalf("a row of labels without a class should panic")
+		}
+	}()
+	e.AddScores(mat.NewDense(1, 2, []float64{0.9, 0.1}), mat.NewDense(1, 2, nil))
+}
+
+func TestReportWrite(t *testing.T) {
+	y, lt := toy()
+	e := NewEvaluation(3, 2)
+	e.AddScores(y, lt)
+	r := e.Report()
+
+	var buf bytes.Buffer
+	if err := r.WriteTable(&buf); err != nil {
+		t.Fatal(err)
+	}
+	for _, s := range []string{"precision", "macro avg", "top-2 accuracy", "true\\predicted"} {
+		if !strings.Contains(buf.String(), s) {
+			t.Fatalf("table expect %q in\n%s", s, buf.String())
+		}
+	}
+
+	buf.Reset()
+	if err := r.WriteCSV(&buf); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 6 || lines[1] != "0,0.6666666666666666,0.6666666666666666,0.6666666666666666,3" {
+		t.Fatalf("csv expect 6 lines but got\n%s", buf.String())
+	}
+
+	buf.Reset()
+	if err := r.WriteConfusionCSV(&buf); err != nil {
+		t.Fatal(err)
+	}
+	if expect := "true\\predicted,0,1,2\n0,2,1,0\n1,0,1,1\n2,1,0,0\n"; buf.String() != expect {
+		t.Fatalf("confusion csv expect %q but got %q", expect, buf.String())
+	}
+
+	buf.Reset()
+	if err := r.WriteJSON(&buf); err != nil {
+		t.Fatal(err)
+	}
+	var decoded Report
+	if err := json.Unmarshal(buf.Bytes(), &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if decoded.Total != 6 || decoded.Classes[1].Precision != 0.5 || decoded.TopK[0].K != 2 || decoded.Scored != 6 || decoded.Confusion[2][0] != 1 {
+		t.Fatalf("json expect the report but got %+v", decoded)
+	}
+}
+
+// scores predicts the rows of y whatever x, which are its row numbers.
+type scores struct{ y *mat.Dense }
+
+func (s scores) Predict(x mat.Matrix) mat.Matrix {
+	r, _ := x.Dims()
+	_, c := s.y.Dims()
+	ret := mat.NewDense(r, c, nil)
+	for i := 0; i < r; i++ {
+		ret.SetRow(i, s.y.RawRowView(int(x.At(i, 0))))
+	}
+	return ret
+}
+
+// once loads x and t as one batch.
+type once struct {
+	x, t mat.Matrix
+	done bool
+}
+
+func (l *once) Reset(epoch int) { l.done = false }
+func (l *once) Next() (x, t mat.Matrix, ok bool) {
+	if l.done {
+		return nil, nil, false
+	}
+	l.done = true
+	return l.x, l.t, true
+}
+func (l *once) Err() error { return nil }
+
+func TestEvaluate(t *testing.T) {
+	y, lt := toy()
+	x := mat.NewDense(6, 1, []float64{0, 1, 2, 3, 4, 5})
+	e, err := Evaluate(scores{y}, &once{x: x, t: lt}, 3, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if e.Total() != 6 || e.Scored != 6 || e.Hits[0] != 5 {
+		t.Fatalf("expect 6 examples with 5 top-2 hits but got %d, %d", e.Total(), e.Hits[0])
+	}
+}
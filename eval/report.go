@@ -0,0 +1,176 @@
+package eval
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"text/tabwriter"
+)
+
+// Metrics are the precision, the recall and the F1 score of a class or
+// their average. A ratio whose denominator is 0 is 0.
+type Metrics struct {
+	Precision float64 `json:"precision"`
+	Recall    float64 `json:"recall"`
+	F1        float64 `json:"f1"`
+}
+
+// ClassReport are the Metrics of a class with its number of examples.
+type ClassReport struct {
+	Name string `json:"name"`
+	Metrics
+	Support int `json:"support"`
+}
+
+// TopK is the ratio of the examples counted with their scores whose class
+// is among the K best scores.
+type TopK struct {
+	K        int     `json:"k"`
+	Accuracy float64 `json:"accuracy"`
+}
+
+// Report is the summary of an Evaluation. Macro is the mean of the metrics
+// of the classes, Micro the metrics of the counts of all the classes, which
+// are all the accuracy for examples of a single class.
+type Report struct {
+	Total     int           `json:"total"`
+	Accuracy  float64       `json:"accuracy"`
+	TopK      []TopK        `json:"top_k,omitempty"`
+	Scored    int           `json:"scored"`
+	Classes   []ClassReport `json:"classes"`
+	Macro     Metrics       `json:"macro"`
+	Micro     Metrics       `json:"micro"`
+	Confusion [][]int       `json:"confusion"`
+}
+
+func (e *Evaluation) Report() *Report {
+	n := e.Classes()
+	r := &Report{Total: e.Total(), Scored: e.Scored, Confusion: e.Confusion}
+
+	// tp, fp, fn : the counts of all the classes
+	tp, fp, fn := 0, 0, 0
+	for k := 0; k < n; k++ {
+		support, predicted := 0, 0
+		for i := 0; i < n; i++ {
+			support += e.Confusion[k][i]
+			predicted += e.Confusion[i][k]
+		}
+		hit := e.Confusion[k][k]
+		tp += hit
+		fp += predicted - hit
+		fn += support - hit
+
+		m := newMetrics(hit, predicted, support)
+		r.Classes = append(r.Classes, ClassReport{Name: e.Name(k), Metrics: m, Support: support})
+		r.Macro.Precision += m.Precision / float64(n)
+		r.Macro.Recall += m.Recall / float64(n)
+		r.Macro.F1 += m.F1 / float64(n)
+	}
+	r.Micro = newMetrics(tp, tp+fp, tp+fn)
+	r.Accuracy = ratio(tp, r.Total)
+	for i, k := range e.TopK {
+		r.TopK = append(r.TopK, TopK{K: k, Accuracy: ratio(e.Hits[i], e.Scored)})
+	}
+	return r
+}
+
+func newMetrics(hit, predicted, support int) Metrics {
+	m := Metrics{Precision: ratio(hit, predicted), Recall: ratio(hit, support)}
+	if m.Precision+m.Recall > 0 {
+		m.F1 = 2 * m.Precision * m.Recall / (m.Precision + m.Recall)
+	}
+	return m
+}
+
+func ratio(a, b int) float64 {
+	if b == 0 {
+		return 0
+	}
+	return float64(a) / float64(b)
+}
+
+// WriteTable writes the metrics of the classes, their averages and the
+// accuracies, then the confusion matrix with a row per true class and a
+// column per predicted class, as aligned text.
+func (r *Report) WriteTable(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(tw, "class\tprecision\trecall\tf1\tsupport\t")
+	for _, c := range r.Classes {
+		fmt.Fprintf(tw, "%s\t%.4f\t%.4f\t%.4f\t%d\t\n", c.Name, c.Precision, c.Recall, c.F1, c.Support)
+	}
+	fmt.Fprintln(tw, "\t\t\t\t\t")
+	fmt.Fprintf(tw, "macro avg\t%.4f\t%.4f\t%.4f\t%d\t\n", r.Macro.Precision, r.Macro.Recall, r.Macro.F1, r.Total)
+	fmt.Fprintf(tw, "micro avg\t%.4f\t%.4f\t%.4f\t%d\t\n", r.Micro.Precision, r.Micro.Recall, r.Micro.F1, r.Total)
+	fmt.Fprintf(tw, "accuracy\t\t\t%.4f\t%d\t\n", r.Accuracy, r.Total)
+	for _, k := range r.TopK {
+		fmt.Fprintf(tw, "top-%d accuracy\t\t\t%.4f\t%d\t\n", k.K, k.Accuracy, r.Scored)
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	fmt.Fprintln(w)
+	tw = tabwriter.NewWriter(w, 0, 0, 1, ' ', tabwriter.AlignRight)
+	fmt.Fprint(tw, "true\\predicted\t")
+	for _, c := range r.Classes {
+		fmt.Fprintf(tw, "%s\t", c.Name)
+	}
+	fmt.Fprintln(tw)
+	for i, row := range r.Confusion {
+		fmt.Fprintf(tw, "%s\t", r.Classes[i].Name)
+		for _, v := range row {
+			fmt.Fprintf(tw, "%d\t", v)
+		}
+		fmt.Fprintln(tw)
+	}
+	return tw.Flush()
+}
+
+// WriteCSV writes the metrics of the classes followed by the rows
+// "macro avg" and "micro avg".
+func (r *Report) WriteCSV(w io.Writer) error {
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"class", "precision", "recall", "f1", "support"})
+	row := func(name string, m Metrics, support int) {
+		cw.Write([]string{name, formatFloat(m.Precision), formatFloat(m.Recall), formatFloat(m.F1), strconv.Itoa(support)})
+	}
+	for _, c := range r.Classes {
+		row(c.Name, c.Metrics, c.Support)
+	}
+	row("macro avg", r.Macro, r.Total)
+	row("micro avg", r.Micro, r.Total)
+	cw.Flush()
+	return cw.Error()
+}
+
+// WriteConfusionCSV writes the confusion matrix with a header of the
+// predicted classes and the true class first on each row.
+func (r *Report) WriteConfusionCSV(w io.Writer) error {
+	cw := csv.NewWriter(w)
+	header := []string{"true\\predicted"}
+	for _, c := range r.Classes {
+		header = append(header, c.Name)
+	}
+	cw.Write(header)
+	for i, row := range r.Confusion {
+		rec := []string{r.Classes[i].Name}
+		for _, v := range row {
+			rec = append(rec, strconv.Itoa(v))
+		}
+		cw.Write(rec)
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+func (r *Report) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(r)
+}
+
+func formatFloat(v float64) string {
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
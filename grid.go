@@ -0,0 +1,234 @@
+package gocnn
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"math"
+	"strconv"
+	"unicode"
+)
+
+// Grid renders the samples of an Image as tiles of one picture: grayscale
+// for 1 channel, RGB for 3 channels and the first channel otherwise. With
+// Labels, each tile is captioned with its label and, with Predicted, the
+// predicted label below in green when right or in red when wrong, the tile
+// being framed in red.
+type Grid struct {
+	// Cols is the number of tiles per row, about the square root of the
+	// number of samples if 0.
+	Cols int
+	// Scale is the size in pixels of a value, 1 if 0.
+	Scale int
+	// Pad is the space around a tile, 1 if 0.
+	Pad int
+	// Min and Max are mapped to black and white, or the range of the values
+	// of the image if equal. PerTile maps the range of each tile instead.
+	Min     float64
+	Max     float64
+	PerTile bool
+
+	Labels    []int
+	Predicted []int
+	// Names are the names of the labels, the labels themselves if nil.
+	Names []string
+}
+
+var (
+	gridBackground = color.RGBA{32, 32, 32, 255}
+	gridRight      = color.RGBA{0, 200, 0, 255}
+	gridWrong      = color.RGBA{230, 0, 0, 255}
+	gridText       = color.RGBA{255, 255, 255, 255}
+)
+
+// WritePNG fails for an image without sample, Labels or Predicted not of
+// one label per sample, or Predicted without Labels.
+func (g *Grid) WritePNG(w io.Writer, img Image) error {
+	if err := g.check(img); err != nil {
+		return err
+	}
+	return png.Encode(w, g.Render(img))
+}
+
+// Render panics where WritePNG fails.
+func (g *Grid) Render(img Image) *image.RGBA {
+	if err := g.check(img); err != nil {
+		panic(err)
+	}
+	s := img.Shape()
+	cols := g.Cols
+	if cols <= 0 {
+		cols = int(math.Ceil(math.Sqrt(float64(s.N))))
+	}
+	rows := (s.N + cols - 1) / cols
+	scale := g.Scale
+	if scale <= 0 {
+		scale = 1
+	}
+	pad := g.Pad
+	if pad <= 0 {
+		pad = 1
+	}
+	tileW, tileH := s.Col*scale, s.Row*scale
+	captionH := 0
+	if g.Labels != nil {
+		captionH = fontHeight + 1
+		if g.Predicted != nil {
+			captionH *= 2
+		}
+	}
+	cellW, cellH := tileW+2*pad, tileH+captionH+2*pad
+
+	ret := image.NewRGBA(image.Rect(0, 0, cols*cellW, rows*cellH))
+	fill(ret, ret.Bounds(), gridBackground)
+
+	min, max := g.Min, g.Max
+	if min == max && !g.PerTile {
+		min, max = valueRange(img, 0, s.N)
+	}
+	for n := 0; n < s.N; n++ {
+		x0, y0 := (n%cols)*cellW+pad, (n/cols)*cellH+pad
+		if g.PerTile {
+			min, max = valueRange(img, n, n+1)
+		}
+		if g.wrong(n) {
+			fill(ret, image.Rect(x0-pad, y0-pad, x0+tileW+pad, y0+tileH+pad), gridWrong)
+		}
+		for r := 0; r < tileH; r++ {
+			for c := 0; c < tileW; c++ {
+				ret.Set(x0+c, y0+r, pixel(img, n, r/scale, c/scale, min, max))
+			}
+		}
+		if g.Labels == nil {
+			continue
+		}
+		y := y0 + tileH + 1
+		drawText(ret, x0, y, x0+tileW, g.name(g.Labels[n]), gridText)
+		if g.Predicted != nil {
+			col := gridRight
+			if g.wrong(n) {
+				col = gridWrong
+			}
+			drawText(ret, x0, y+fontHeight+1, x0+tileW, g.name(g.Predicted[n]), col)
+		}
+	}
+	return ret
+}
+
+func (g *Grid) check(img Image) error {
+	n := img.Shape().N
+	if n <= 0 {
+		return fmt.Errorf("no sample to render")
+	}
+	if g.Labels != nil && len(g.Labels) != n {
+		return fmt.Errorf("expect %d labels but got %d", n, len(g.Labels))
+	}
+	if g.Predicted != nil && g.Labels == nil {
+		return fmt.Errorf("predicted labels need the labels")
+	}
+	if g.Predicted != nil && len(g.Predicted) != n {
+		return fmt.Errorf("expect %d predicted labels but got %d", n, len(g.Predicted))
+	}
+	return nil
+}
+
+func (g *Grid) wrong(n int) bool {
+	return g.Labels != nil && g.Predicted != nil && g.Labels[n] != g.Predicted[n]
+}
+
+func (g *Grid) name(label int) string {
+	if g.Names != nil && label >= 0 && label < len(g.Names) {
+		return g.Names[label]
+	}
+	return strconv.Itoa(label)
+}
+
+// pixel is the colour of the value at (r, c) of the n-th sample.
+func pixel(img Image, n, r, c int, min, max float64) color.Color {
+	level := func(ch int) uint8 {
+		v := img.Get(n, ch, r, c)
+		if max <= min {
+			return 0
+		}
+		v = (v - min) / (max - min)
+		return uint8(math.Max(0, math.Min(1, v))*255 + 0.5)
+	}
+	if img.Shape().Ch == 3 {
+		return color.RGBA{level(0), level(1), level(2), 255}
+	}
+	l := level(0)
+	return color.RGBA{l, l, l, 255}
+}
+
+// valueRange is the smallest and the largest values of the samples from
+// to to.
+func valueRange(img Image, from, to int) (min, max float64) {
+	s := img.Shape()
+	min, max = math.Inf(1), math.Inf(-1)
+	for n := from; n < to; n++ {
+		for ch := 0; ch < s.Ch; ch++ {
+			for r := 0; r < s.Row; r++ {
+				for c := 0; c < s.Col; c++ {
+					v := img.Get(n, ch, r, c)
+					min, max = math.Min(min, v), math.Max(max, v)
+				}
+			}
+		}
+	}
+	return min, max
+}
+
+func fill(img *image.RGBA, r image.Rectangle, c color.Color) {
+	r = r.Intersect(img.Bounds())
+	for y := r.Min.Y; y < r.Max.Y; y++ {
+		for x := r.Min.X; x < r.Max.X; x++ {
+			img.Set(x, y, c)
+		}
+	}
+}
+
+const (
+	fontWidth  = 3
+	fontHeight = 5
+)
+
+// glyphs are the 3x5 bitmaps of the characters of the captions, row by row.
+// Lower case letters are drawn in upper case and unknown characters as '?'.
+var glyphs = map[rune]string{
+	'0': "111101101101111", '1': "010110010010111", '2': "111001111100111",
+	'3': "111001111001111", '4': "101101111001001", '5': "111100111001111",
+	'6': "111100111101111", '7': "111001010010010", '8': "111101111101111",
+	'9': "111101111001111",
+	'A': "010101111101101", 'B': "110101110101110", 'C': "011100100100011",
+	'D': "110101101101110", 'E': "111100110100111", 'F': "111100110100100",
+	'G': "011100101101011", 'H': "101101111101101", 'I': "111010010010111",
+	'J': "001001001101010", 'K': "101101110101101", 'L': "100100100100111",
+	'M': "101111111101101", 'N': "110101101101101", 'O': "010101101101010",
+	'P': "110101110100100", 'Q': "010101101110011", 'R': "110101110101101",
+	'S': "011100010001110", 'T': "111010010010010", 'U': "101101101101111",
+	'V': "101101101101010", 'W': "101101111111101", 'X': "101101010101101",
+	'Y': "101101010010010", 'Z': "111001010100111",
+	' ': "000000000000000", '-': "000000111000000", '/': "001001010100100",
+	'.': "000000000000010", ':': "000010000010000", '?': "111001010000010",
+}
+
+// drawText draws s from (x, y), clipped at the column right.
+func drawText(img *image.RGBA, x, y, right int, s string, c color.Color) {
+	for _, r := range s {
+		if x+fontWidth > right {
+			return
+		}
+		g, ok := glyphs[unicode.ToUpper(r)]
+		if !ok {
+			g = glyphs['?']
+		}
+		for i, b := range g {
+			if b == '1' {
+				img.Set(x+i%fontWidth, y+i/fontWidth, c)
+			}
+		}
+		x += fontWidth + 1
+	}
+}
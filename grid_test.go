@@ -0,0 +1,114 @@
+package gocnn
+
+import (
+	"bytes"
+	"image/color"
+	"image/png"
+	"testing"
+)
+
+func TestGrid(t *testing.T) {
+	// 3 samples of 1x2x2 valued 0, 1, 2, 3 from the top left
+	img := NewImages(NewShape(3, 1, 2, 2), []float64{
+		0, 1, 2, 3,
+		0, 0, 0, 0,
+		3, 3, 3, 3,
+	})
+	cases := []struct {
+		title  string
+		grid   *Grid
+		width  int
+		height int
+		pixels map[[2]int]color.RGBA
+	}{
+		{
+			title: "plain",
+			grid:  &Grid{Cols: 2},
+			width: 8, height: 8,
+			pixels: map[[2]int]color.RGBA{
+				{1, 1}: {0, 0, 0, 255},
+				{2, 1}: {85, 85, 85, 255},
+				{2, 2}: {255, 255, 255, 255},
+				{0, 0}: gridBackground,
+				{1, 5}: {255, 255, 255, 255},
+			},
+		},
+		{
+			title: "scaled per tile",
+			grid:  &Grid{Scale: 2, Pad: 2, PerTile: true},
+			width: 16, height: 16,
+			pixels: map[[2]int]color.RGBA{
+				{3, 3}:  {0, 0, 0, 255},
+				{4, 2}:  {85, 85, 85, 255},
+				{10, 2}: {0, 0, 0, 255},
+			},
+		},
+		{
+			title:  "labels",
+			grid:   &Grid{Cols: 3, Scale: 2, Labels: []int{1, 2, 3}, Predicted: []int{1, 0, 3}},
+			width:  18,
+			height: 18,
+			pixels: map[[2]int]color.RGBA{
+				// frame of the misclassified second sample
+				{6, 0}:  gridWrong,
+				{12, 0}: gridBackground,
+				{2, 6}:  gridText,
+				{8, 12}: gridWrong,
+				{2, 12}: gridRight,
+			},
+		},
+	}
+	for _, c := range cases {
+		var buf bytes.Buffer
+		if err := c.grid.WritePNG(&buf, img); err != nil {
+			t.Fatalf("%s: %v", c.title, err)
+		}
+		out, err := png.Decode(&buf)
+		if err != nil {
+			t.Fatalf("%s: %v", c.title, err)
+		}
+		b := out.Bounds()
+		if b.Dx() != c.width || b.Dy() != c.height {
+			t.Fatalf("%s expect %dx%d but got %dx%d", c.title, c.width, c.height, b.Dx(), b.Dy())
+		}
+		for at, expect := range c.pixels {
+			r, g, bl, a := out.At(at[0], at[1]).RGBA()
+			actual := color.RGBA{uint8(r >> 8), uint8(g >> 8), uint8(bl >> 8), uint8(a >> 8)}
+			if actual != expect {
+				t.Fatalf("%s %v expect %v but got %v", c.title, at, expect, actual)
+			}
+		}
+	}
+}
+
+func TestDrawText(t *testing.T) {
+	g := &Grid{Labels: []int{0}, Names: []string{"t-1"}}
+	out := g.Render(NewImages(NewShape(1, 1, 5, 12), make([]float64, 60)))
+	// the bar of "t", the dash and the foot of "1"
+	for _, at := range [][2]int{{1, 7}, {6, 9}, {10, 11}} {
+		if out.At(at[0], at[1]) != gridText {
+			t.Fatalf("%v expect %v but got %v", at, gridText, out.At(at[0], at[1]))
+		}
+	}
+}
+
+func TestGridError(t *testing.T) {
+	img := NewImages(NewShape(2, 1, 1, 1), []float64{0, 1})
+	cases := []struct {
+		title string
+		grid  *Grid
+		img   Image
+		want  string
+	}{
+		{"no sample", &Grid{}, NewImages(NewShape(0, 1, 1, 1), nil), "no sample to render"},
+		{"labels", &Grid{Labels: []int{0}}, img, "expect 2 labels but got 1"},
+		{"predicted", &Grid{Labels: []int{0, 1}, Predicted: []int{0, 1, 2}}, img, "expect 2 predicted labels but got 3"},
+		{"predicted without labels", &Grid{Predicted: []int{0, 1}}, img, "predicted labels need the labels"},
+	}
+	for _, c := range cases {
+		err := c.grid.WritePNG(&bytes.Buffer{}, c.img)
+		if err == nil || err.Error() != c.want {
+			t.Fatalf("%s expect %q but got %v", c.title, c.want, err)
+		}
+	}
+}
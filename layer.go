@@ -3,7 +3,9 @@ package gocnn
 import (
 	mat "github.com/gonum/matrix/mat64"
 
+	"github.com/ajiyoshi/gocnn/batch"
 	"github.com/ajiyoshi/gocnn/matrix"
+	"github.com/ajiyoshi/gocnn/nd"
 	"github.com/ajiyoshi/gocnn/optimizer"
 )
 
@@ -38,8 +40,7 @@ func (c *Convolution) Forward(x Image) Image {
 		panic("number of channels was not match")
 	}
 
-	outRow := 1 + (xs.Row+2*c.Pad-ws.Row)/c.Stride
-	outCol := 1 + (xs.Row+2*c.Pad-ws.Row)/c.Stride
+	out := c.OutputShape(xs)
 
 	// col : (xs.n*outRow*outCol, xs.ch*ws.row*ws.col)
 	c.col = Im2col(x, ws.Row, ws.Col, c.Stride, c.Pad)
@@ -53,7 +54,18 @@ func (c *Convolution) Forward(x Image) Image {
 		return c.Bias.At(j, 0) + val
 	}, ret)
 
-	return NewReshaped(NewShape(xs.N, outRow, outCol, ws.N), ret).Transpose(0, 3, 1, 2)
+	return NewReshaped(NewShape(xs.N, out.Row, out.Col, ws.N), ret).Transpose(0, 3, 1, 2)
+}
+
+// OutputShape is the shape of Forward(x) for x of shape in.
+func (c *Convolution) OutputShape(in *Shape) *Shape {
+	ws := c.Weight.Shape()
+	return &Shape{
+		N:   in.N,
+		Ch:  ws.N,
+		Row: outSize(in.Row, ws.Row, c.Stride, c.Pad),
+		Col: outSize(in.Col, ws.Col, c.Stride, c.Pad),
+	}
 }
 
 func (c *Convolution) Backword(doutImg Image) Image {
@@ -88,6 +100,17 @@ func (c *Convolution) Update() {
 	c.Optimizer.UpdateBias(c.Bias, c.dBias)
 }
 
+func (c *Convolution) Params() []optimizer.Param {
+	if c.dWeight == nil {
+		c.dWeight = NewEmptyStrage(c.Weight.Shape())
+		c.dBias = mat.NewVector(c.Bias.Len(), nil)
+	}
+	return []optimizer.Param{
+		{Name: "weight", Value: c.Weight.ToArray(), Grad: c.dWeight.ToArray()},
+		{Name: "bias", Value: nd.NewVectorArray(c.Bias), Grad: nd.NewVectorArray(c.dBias)},
+	}
+}
+
 type Pooling struct {
 	Row    int
 	Col    int
@@ -122,10 +145,18 @@ func (p *Pooling) Forward(x Image) Image {
 
 	out := maxEachRow(col)
 
-	s := x.Shape()
-	outRow := 1 + (s.Row-p.Row)/p.Stride
-	outCol := 1 + (s.Col-p.Col)/p.Stride
-	return NewImages(NewShape(s.N, outRow, outCol, s.Ch), out).Transpose(0, 3, 1, 2)
+	s := p.OutputShape(x.Shape())
+	return NewImages(NewShape(s.N, s.Row, s.Col, s.Ch), out).Transpose(0, 3, 1, 2)
+}
+
+// OutputShape is the shape of Forward(x) for x of shape in.
+func (p *Pooling) OutputShape(in *Shape) *Shape {
+	return &Shape{
+		N:   in.N,
+		Ch:  in.Ch,
+		Row: outSize(in.Row, p.Row, p.Stride, p.Pad),
+		Col: outSize(in.Col, p.Col, p.Stride, p.Pad),
+	}
 }
 
 func (p *Pooling) Backword(doutImage Image) Image {
@@ -187,6 +218,39 @@ func (r *ReLU) Backword(dout Image) Image {
 
 func (r *ReLU) Update() {}
 
+// OutputShape is the shape of Forward(x) for x of shape in.
+func (r *ReLU) OutputShape(in *Shape) *Shape {
+	return in
+}
+
+// Normalize applies a batch.Normalize fitted on the flattened images.
+type Normalize struct {
+	Layer *batch.Normalize
+}
+
+func (l *Normalize) Forward(x Image) Image {
+	s := x.Shape()
+	return NewReshaped(s, l.Layer.Forward(x.ToMatrix(s.N, s.Size()/s.N)))
+}
+func (l *Normalize) Backword(dout Image) Image {
+	s := dout.Shape()
+	return NewReshaped(s, l.Layer.Backward(dout.ToMatrix(s.N, s.Size()/s.N)))
+}
+
+func (l *Normalize) Update() {}
+
+// OutputShape is the shape of Forward(x) for x of shape in.
+func (l *Normalize) OutputShape(in *Shape) *Shape {
+	return in
+}
+
+func outSize(in, filter, stride, pad int) int {
+	if in+2*pad < filter {
+		return 0
+	}
+	return 1 + (in+2*pad-filter)/stride
+}
+
 func mul(x, y mat.Matrix) *mat.Dense {
 	var ret mat.Dense
 	ret.Mul(x, y)
@@ -192,3 +192,19 @@ func TestPooling(t *testing.T) {
 		}
 	}
 }
+
+func TestConvolutionParams(t *testing.T) {
+	conv := NewConvolution(NewShape(2, 1, 3, 3), 1, 0, nil)
+	ps := conv.Params()
+	if len(ps) != 2 || ps[0].Name != "weight" || ps[1].Name != "bias" {
+		t.Fatalf("unexpected params %v", ps)
+	}
+	if !ps[0].Grad.Shape().Equals(nd.NewShape(2, 1, 3, 3)) {
+		t.Fatalf("expect gradient of shape (2, 1, 3, 3) but got %v", ps[0].Grad.Shape())
+	}
+	ps[0].Value.Set(5, 1, 0, 2, 2)
+	ps[1].Value.Set(7, 1)
+	if conv.Weight.Get(1, 0, 2, 2) != 5 || conv.Bias.At(1, 0) != 7 {
+		t.Fatalf("params should share storage with the layer")
+	}
+}
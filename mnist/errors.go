@@ -0,0 +1,62 @@
+package mnist
+
+import (
+	"fmt"
+)
+
+/*
+FormatError IDX ファイルのヘッダや中身が不正
+*/
+type FormatError struct {
+	Path string
+	Msg  string
+}
+
+func (e *FormatError) Error() string {
+	if e.Path == "" {
+		return e.Msg
+	}
+	return fmt.Sprintf("%s: %s", e.Path, e.Msg)
+}
+
+/*
+IndexError ヘッダのレコード数 Num の範囲外の Index を読もうとした
+*/
+type IndexError struct {
+	Index int
+	Num   int
+}
+
+func (e *IndexError) Error() string {
+	return fmt.Sprintf("index %d out of range [0, %d)", e.Index, e.Num)
+}
+
+/*
+TruncatedError Offset バイト目から Want バイト読もうとしたが Got バイトしかなかった
+*/
+type TruncatedError struct {
+	Offset int64
+	Want   int
+	Got    int
+}
+
+func (e *TruncatedError) Error() string {
+	return fmt.Sprintf("bad file: try to read %d bytes at %d but got %d bytes", e.Want, e.Offset, e.Got)
+}
+
+func checkIndex(i, num int) error {
+	if i < 0 || i >= num {
+		return &IndexError{Index: i, Num: num}
+	}
+	return nil
+}
+
+/*
+withPath ヘッダを読んだときのエラーにファイル名を付ける
+*/
+func withPath(err error, path string) error {
+	if e, ok := err.(*FormatError); ok && e.Path == "" {
+		e.Path = path
+	}
+	return err
+}
@@ -4,47 +4,75 @@ import (
 	"bytes"
 	"encoding/binary"
 	"fmt"
-	"golang.org/x/exp/mmap"
 	"image"
 	"image/color"
 	"image/png"
 	"io"
+
+	"github.com/ajiyoshi/gocnn/dataset"
+	"github.com/ajiyoshi/gocnn/nd"
 )
 
 /*
 Mnist ラベルとイメージをセットにした構造体
 */
 type Mnist struct {
-	Images *Image
-	Labels *Label
-	index  int
+	Images  *Image
+	Labels  *Label
+	Variant *Variant
+	index   int
+	x       []float64
 }
 
+var (
+	_ dataset.Dataset = (*Mnist)(nil)
+	_ dataset.Labeler = (*Mnist)(nil)
+	_ dataset.Reader  = (*Mnist)(nil)
+)
+
 /*
 Image イメージ構造体
 */
 type Image struct {
-	m           *mmap.ReaderAt
+	m           source
 	buf         []byte
+	offset      int64
 	MagicNumber int
 	Num         int
 	Rows        int
 	Cols        int
+	Transposed  bool
 }
 
 /*
 Label ラベル構造体
 */
 type Label struct {
-	m           *mmap.ReaderAt
+	m           source
+	offset      int64
 	MagicNumber int
 	Num         int
 }
 
 /*
-NewMnist イメージとラベルのインデクスファイルを開く
+IDX ファイルのマジックナンバー。上位2バイトは0、3バイト目はデータ型(0x08 は unsigned byte)、4バイト目は次元数
+*/
+const (
+	ImageMagic = 0x00000803
+	LabelMagic = 0x00000801
+)
+
+/*
+NewMnist MNIST のイメージとラベルのインデクスファイルを開く。.gz のファイルはそのまま渡せる
 */
 func NewMnist(image, label string) (*Mnist, error) {
+	return Open(MNIST, image, label)
+}
+
+/*
+Open v の形式のイメージとラベルのインデクスファイルを開く。ヘッダが不正な場合、ファイルがヘッダのレコード数より短い場合、イメージとラベルの数が合わない場合、ラベルが v のクラスにない場合は *FormatError
+*/
+func Open(v *Variant, image, label string) (*Mnist, error) {
 	mi, err := NewMnistImage(image)
 	if err != nil {
 		return nil, err
@@ -54,14 +82,38 @@ func NewMnist(image, label string) (*Mnist, error) {
 		mi.Close()
 		return nil, err
 	}
-	return &Mnist{
-		Images: mi,
-		Labels: ml,
-	}, nil
+	m := &Mnist{
+		Images:  mi,
+		Labels:  ml,
+		Variant: v,
+	}
+	mi.Transposed = v.Transposed
+	if err := m.check(); err != nil {
+		m.Close()
+		return nil, withPath(err, label)
+	}
+	return m, nil
+}
+
+func (m *Mnist) check() error {
+	if m.Images.Num != m.Labels.Num {
+		return &FormatError{Msg: fmt.Sprintf("%d images but %d labels", m.Images.Num, m.Labels.Num)}
+	}
+	classes := m.Classes()
+	for i := 0; i < m.Labels.Num; i++ {
+		b, err := m.Labels.At(i)
+		if err != nil {
+			return err
+		}
+		if l := int(b) - m.offset(); l < 0 || l >= classes {
+			return &FormatError{Msg: fmt.Sprintf("label %d of %s at %d should be in [0, %d)", b, m.Variant.Name, i, classes)}
+		}
+	}
+	return nil
 }
 
 /*
-Close mmap を閉じる
+Close イメージとラベルのファイルを閉じる
 */
 func (m *Mnist) Close() {
 	m.Images.Close()
@@ -83,7 +135,7 @@ func (m *Mnist) Jump(i int) error {
 /*
 Label Mnist.Jump() でロードしたラベル
 */
-func (m *Mnist) Label() byte {
+func (m *Mnist) Label() (byte, error) {
 	return m.Labels.At(m.index)
 }
 
@@ -95,11 +147,107 @@ func (m *Mnist) Image() []byte {
 }
 
 /*
-At i番目のイメージとラベルを返す
+At i番目のイメージとラベルを返す。イメージは次の呼び出しで上書きされる
+*/
+func (m *Mnist) At(i int) ([]byte, byte, error) {
+	if err := m.Jump(i); err != nil {
+		return nil, 0, err
+	}
+	label, err := m.Label()
+	if err != nil {
+		return nil, 0, err
+	}
+	return m.Image(), label, nil
+}
+
+/*
+Len イメージの数
+*/
+func (m *Mnist) Len() int {
+	return m.Images.Num
+}
+
+/*
+Get dataset.Dataset の実装。i番目のイメージを [0, 1] に変換したものとラベルを返す。スライスは次の呼び出しで上書きされる。
+dataset.Dataset はエラーを返せないので、読めなければ Read が返すエラーで panic する。エラーを扱うなら At か Read を使うこと
+*/
+func (m *Mnist) Get(i int) ([]float64, int) {
+	if m.x == nil {
+		m.x = make([]float64, m.Images.Rows*m.Images.Cols)
+	}
+	label, err := m.Read(i, m.x)
+	if err != nil {
+		panic(err)
+	}
+	return m.x, label
+}
+
+/*
+Read i番目のイメージを [0, 1] に変換して x に読み込み、ラベルを返す。複数の goroutine から呼んでよい
+*/
+func (m *Mnist) Read(i int, x []float64) (int, error) {
+	buf := make([]byte, m.Images.Rows*m.Images.Cols)
+	if err := m.Images.ReadAt(i, buf); err != nil {
+		return 0, err
+	}
+	LoadVec(buf, x)
+	return m.ReadLabel(i)
+}
+
+/*
+ReadLabel i番目のラベル。範囲外なら *IndexError
+*/
+func (m *Mnist) ReadLabel(i int) (int, error) {
+	b, err := m.Labels.At(i)
+	if err != nil {
+		return 0, err
+	}
+	return int(b) - m.offset(), nil
+}
+
+/*
+LabelAt dataset.Labeler の実装。ReadLabel が返すエラーで panic する
+*/
+func (m *Mnist) LabelAt(i int) int {
+	label, err := m.ReadLabel(i)
+	if err != nil {
+		panic(err)
+	}
+	return label
+}
+
+func (m *Mnist) offset() int {
+	if m.Variant == nil {
+		return 0
+	}
+	return m.Variant.LabelOffset
+}
+
+/*
+Shape イメージの形 (チャンネル, 縦, 横)
 */
-func (m *Mnist) At(i int) ([]byte, byte) {
-	m.Jump(i)
-	return m.Image(), m.Label()
+func (m *Mnist) Shape() []int {
+	return []int{1, m.Images.Rows, m.Images.Cols}
+}
+
+/*
+Classes ラベルの種類の数
+*/
+func (m *Mnist) Classes() int {
+	if m.Variant == nil {
+		return 10
+	}
+	return len(m.Variant.Classes)
+}
+
+/*
+ClassName ラベルのクラス名
+*/
+func (m *Mnist) ClassName(label int) string {
+	if m.Variant == nil {
+		return fmt.Sprint(label)
+	}
+	return m.Variant.Classes[label]
 }
 
 /*
@@ -110,10 +258,10 @@ func (m *Mnist) DumpPng(w io.Writer) error {
 }
 
 /*
-NewMnistImage Image オブジェクトをロードする。使い終わったら Close() すること
+NewMnistImage Image オブジェクトをロードする。非圧縮のファイルは mmap し、.gz のファイルはメモリに展開する。使い終わったら Close() すること
 */
 func NewMnistImage(path string) (*Image, error) {
-	m, err := mmap.Open(path)
+	m, err := openSource(path)
 	if err != nil {
 		return nil, err
 	}
@@ -121,7 +269,7 @@ func NewMnistImage(path string) (*Image, error) {
 	ret, err := initMnistImage(m)
 	if err != nil {
 		m.Close()
-		return nil, err
+		return nil, withPath(err, path)
 	}
 
 	return ret, nil
@@ -130,36 +278,32 @@ func NewMnistImage(path string) (*Image, error) {
 /*
 initMnistImage マジックナンバー、レコード数、イメージの縦横サイズをロード
 */
-func initMnistImage(m *mmap.ReaderAt) (*Image, error) {
-	magic, err := Int32At(m, 0)
+func initMnistImage(m source) (*Image, error) {
+	h, err := readHeader(m, 3)
 	if err != nil {
 		return nil, err
 	}
-	num, err := Int32At(m, 4)
-	if err != nil {
-		return nil, err
+	num, rows, cols := h.Shape[0], h.Shape[1], h.Shape[2]
+	if rows <= 0 || cols <= 0 {
+		return nil, &FormatError{Msg: fmt.Sprintf("image size %dx%d should be positive", rows, cols)}
 	}
-	rows, err := Int32At(m, 8)
-	if err != nil {
-		return nil, err
-	}
-	cols, err := Int32At(m, 12)
-	if err != nil {
-		return nil, err
+	// rows と cols は 2^31 未満なので int64 で溢れない
+	if size := int64(rows) * int64(cols); size > int64(m.Len()) {
+		return nil, &FormatError{Msg: fmt.Sprintf("image size %dx%d is larger than the file of %d bytes", rows, cols, m.Len())}
 	}
-
 	return &Image{
 		m:           m,
 		buf:         make([]byte, rows*cols),
-		MagicNumber: int(magic),
-		Num:         int(num),
-		Rows:        int(rows),
-		Cols:        int(cols),
+		offset:      int64(h.Len()),
+		MagicNumber: ImageMagic,
+		Num:         num,
+		Rows:        rows,
+		Cols:        cols,
 	}, nil
 }
 
 /*
-Close mmapを閉じる
+Close ファイルを閉じる
 */
 func (m *Image) Close() error {
 	return m.m.Close()
@@ -169,14 +313,42 @@ func (m *Image) Close() error {
 Jump i番目のイメージをロード
 */
 func (m *Image) Jump(i int) error {
-	offset := 16 + int64(i)*int64(m.Rows)*int64(m.Cols)
-	err := MustRead(m.m, offset, m.buf)
-	if err != nil {
+	return m.ReadAt(i, m.buf)
+}
+
+/*
+ReadAt i番目のイメージを buf に読み込む。Jump と違って複数の goroutine から呼んでよい。i が範囲外なら *IndexError
+*/
+func (m *Image) ReadAt(i int, buf []byte) error {
+	if err := checkIndex(i, m.Num); err != nil {
 		return err
 	}
+	if len(buf) < m.Rows*m.Cols {
+		return fmt.Errorf("buffer of %d bytes is too small for an image of %dx%d", len(buf), m.Rows, m.Cols)
+	}
+	offset := m.offset + int64(i)*int64(m.Rows)*int64(m.Cols)
+	if !m.Transposed {
+		return MustRead(m.m, offset, buf[:m.Rows*m.Cols])
+	}
+	raw := make([]byte, m.Rows*m.Cols)
+	if err := MustRead(m.m, offset, raw); err != nil {
+		return err
+	}
+	transpose(buf, raw, m.Rows, m.Cols)
 	return nil
 }
 
+/*
+transpose 列ごとに並んだ rows x cols のイメージ raw を行ごとに並べて dst に書き込む
+*/
+func transpose(dst, raw []byte, rows, cols int) {
+	for r := 0; r < rows; r++ {
+		for c := 0; c < cols; c++ {
+			dst[r*cols+c] = raw[c*rows+r]
+		}
+	}
+}
+
 /*
 Buf Image.Jump でロードした場所のイメージを返す
 */
@@ -188,21 +360,20 @@ func (m *Image) Buf() []byte {
 DumpPng Image.Jump でロードした場所のイメージをPNG形式で書き出す
 */
 func (m *Image) DumpPng(w io.Writer) error {
-	img := image.NewGray(image.Rect(0, 0, m.Rows, m.Cols))
-	for x := 0; x < m.Rows; x++ {
-		for y := 0; y < m.Cols; y++ {
-			i := x + y*m.Rows
-			img.Set(x, y, color.Gray{m.buf[i]})
+	img := image.NewGray(image.Rect(0, 0, m.Cols, m.Rows))
+	for y := 0; y < m.Rows; y++ {
+		for x := 0; x < m.Cols; x++ {
+			img.Set(x, y, color.Gray{m.buf[x+y*m.Cols]})
 		}
 	}
 	return png.Encode(w, img)
 }
 
 /*
-NewMnistLabel ラベルファイルを開く
+NewMnistLabel ラベルファイルを開く。.gz のファイルはメモリに展開する
 */
 func NewMnistLabel(path string) (*Label, error) {
-	m, err := mmap.Open(path)
+	m, err := openSource(path)
 	if err != nil {
 		return nil, err
 	}
@@ -210,29 +381,49 @@ func NewMnistLabel(path string) (*Label, error) {
 	ret, err := initMnistLabel(m)
 	if err != nil {
 		m.Close()
-		return nil, err
+		return nil, withPath(err, path)
 	}
 
 	return ret, nil
 }
 
-func initMnistLabel(m *mmap.ReaderAt) (*Label, error) {
-	magic, err := Int32At(m, 0)
-	if err != nil {
-		return nil, err
-	}
-	num, err := Int32At(m, 4)
+func initMnistLabel(m source) (*Label, error) {
+	h, err := readHeader(m, 1)
 	if err != nil {
 		return nil, err
 	}
-
 	return &Label{
 		m:           m,
-		MagicNumber: int(magic),
-		Num:         int(num),
+		offset:      int64(h.Len()),
+		MagicNumber: LabelMagic,
+		Num:         h.Shape[0],
 	}, nil
 }
 
+/*
+readHeader unsigned byte の dims 次元の IDX ファイルのヘッダを読み、ファイルの長さが足りているかを確かめる。不正なら *FormatError
+*/
+func readHeader(m source, dims int) (*nd.IdxHeader, error) {
+	h, err := nd.ReadIdxHeader(io.NewSectionReader(m, 0, int64(m.Len())))
+	switch {
+	case err == io.EOF || err == io.ErrUnexpectedEOF:
+		return nil, &FormatError{Msg: fmt.Sprintf("%d bytes are too short for an IDX header", m.Len())}
+	case err != nil:
+		return nil, &FormatError{Msg: err.Error()}
+	}
+	if h.Type != nd.IdxUbyte {
+		return nil, &FormatError{Msg: fmt.Sprintf("IDX data type should be %s but got %s", nd.IdxUbyte, h.Type)}
+	}
+	if len(h.Shape) != dims {
+		return nil, &FormatError{Msg: fmt.Sprintf("IDX file should have %d dimensions but got %d", dims, len(h.Shape))}
+	}
+	n, _ := h.DataLen()
+	if size := int64(h.Len()) + n; int64(m.Len()) < size {
+		return nil, &FormatError{Msg: fmt.Sprintf("truncated IDX file: %v values need %d bytes but got %d", h.Shape, size, m.Len())}
+	}
+	return h, nil
+}
+
 /*
 Close ラベルファイルを閉じる
 */
@@ -241,17 +432,19 @@ func (m *Label) Close() error {
 }
 
 /*
-At i番目のラベルを取得
+At i番目のラベルを取得。i が範囲外なら *IndexError
 */
-func (m *Label) At(i int) byte {
-	offset := 8 + i
-	return m.m.At(offset)
+func (m *Label) At(i int) (byte, error) {
+	if err := checkIndex(i, m.Num); err != nil {
+		return 0, err
+	}
+	return m.m.At(int(m.offset) + i), nil
 }
 
 /*
 Int32At offsetバイト目から BigEndian で int32 をロードする
 */
-func Int32At(m *mmap.ReaderAt, offset int64) (int32, error) {
+func Int32At(m io.ReaderAt, offset int64) (int32, error) {
 	buf := make([]byte, 4)
 	err := MustRead(m, offset, buf)
 	if err != nil {
@@ -267,15 +460,15 @@ func Int32At(m *mmap.ReaderAt, offset int64) (int32, error) {
 }
 
 /*
-MustRead mmap.ReaderAt の offset バイト目から len(buf) 分をロード。len(buf) 分読めなければエラー
+MustRead m の offset バイト目から len(buf) 分をロード。len(buf) 分読めなければ *TruncatedError
 */
-func MustRead(m *mmap.ReaderAt, offset int64, buf []byte) error {
+func MustRead(m io.ReaderAt, offset int64, buf []byte) error {
 	n, err := m.ReadAt(buf, offset)
-	if err != nil {
-		return err
+	if n < len(buf) && (err == nil || err == io.EOF) {
+		return &TruncatedError{Offset: offset, Want: len(buf), Got: n}
 	}
-	if n != len(buf) {
-		panic(fmt.Sprintf("bad file(try to read %d bytes but got %d bytes", len(buf), n))
+	if err != nil && err != io.EOF {
+		return err
 	}
 	return nil
 }
@@ -6,17 +6,21 @@ import (
 	"github.com/gonum/matrix/mat64"
 	"io"
 	"math/rand"
+
+	"github.com/ajiyoshi/gocnn/dataset"
 )
 
 /*
 TrainBuffer 学習用に読み込むバッファ
 */
 type TrainBuffer struct {
-	rows int
-	xCol int
-	tCol int
-	x    []float64
-	t    []float64
+	rows    int
+	xCol    int
+	tCol    int
+	x       []float64
+	t       []float64
+	imgRows int
+	imgCols int
 }
 
 /*
@@ -27,14 +31,32 @@ tCol ラベル1つあたりの次元(mnistなら10)
 */
 func NewTrainBuffer(rows, xCol, tCol int) *TrainBuffer {
 	return &TrainBuffer{
-		rows: rows,
-		xCol: xCol,
-		tCol: tCol,
-		x:    make([]float64, rows*xCol),
-		t:    make([]float64, rows*tCol),
+		rows:    rows,
+		xCol:    xCol,
+		tCol:    tCol,
+		x:       make([]float64, rows*xCol),
+		t:       make([]float64, rows*tCol),
+		imgRows: 28,
+		imgCols: 28,
 	}
 }
 
+/*
+NewDatasetBuffer Dataset の特徴量とラベルの数に合わせた学習用バッファを初期化
+*/
+func NewDatasetBuffer(rows int, d dataset.Dataset) *TrainBuffer {
+	buf := NewTrainBuffer(rows, dataset.Size(d), d.Classes())
+	buf.imgRows, buf.imgCols = dataset.ImageSize(d)
+	return buf
+}
+
+/*
+Rows バッファの行数
+*/
+func (buf *TrainBuffer) Rows() int {
+	return buf.rows
+}
+
 /*
 LoadX 入力データをバッファにコピー
 */
@@ -51,21 +73,30 @@ func (buf *TrainBuffer) LoadX(i int, x []byte) error {
 LoadT ラベルをバッファにコピー
 */
 func (buf *TrainBuffer) LoadT(i int, t byte) {
+	buf.loadLabel(i, int(t))
+}
+
+func (buf *TrainBuffer) loadLabel(i, t int) {
 	offset := i * buf.tCol
-	copy(buf.t[offset:], labels[t])
+	dataset.OneHot(buf.t[offset:offset+buf.tCol], t)
 }
 
 /*
-Load Mnist からイメージとラベルを読み取ってバッファにコピー
+Read Reader から at 番目の特徴量とラベルをバッファに読み込む
 */
-func (buf *TrainBuffer) Load(m *Mnist, at []int) {
-	rows := len(at)
-	for i := 0; i < rows; i++ {
-		n := at[i]
-		x, t := m.At(n)
-		buf.LoadX(i, x)
-		buf.LoadT(i, t)
+func (buf *TrainBuffer) Read(r dataset.Reader, at []int) error {
+	if len(at) > buf.rows {
+		panic(fmt.Sprintf("at most %d rows but got %d", buf.rows, len(at)))
 	}
+	for i, n := range at {
+		offset := i * buf.xCol
+		t, err := r.Read(n, buf.x[offset:offset+buf.xCol])
+		if err != nil {
+			return err
+		}
+		buf.loadLabel(i, t)
+	}
+	return nil
 }
 
 /*
@@ -74,7 +105,7 @@ Dump デバッグ用に、ロードしているMNISTイメージをアスキー
 func (buf *TrainBuffer) Dump(w io.Writer) {
 	for i := 0; i < buf.rows; i++ {
 		offset := buf.xCol * i
-		fmt.Fprintf(w, "%s\n", XToString(buf.x[offset:]))
+		fmt.Fprintf(w, "%s\n", XToStringSize(buf.x[offset:offset+buf.xCol], buf.imgRows, buf.imgCols))
 	}
 }
 
@@ -139,18 +170,25 @@ func GetHoge(data []float64, x, y int) float64 {
 }
 
 func XToString(data []float64) string {
+	return XToStringSize(data, 28, 28)
+}
+
+/*
+XToStringSize rows x cols のイメージを4x4ごとにまとめたアスキーアートにする
+*/
+func XToStringSize(data []float64, rows, cols int) string {
 	w := bytes.NewBuffer(make([]byte, 0, 50))
-	buf := make([]float64, 49)
+	r, c := (rows+3)/4, (cols+3)/4
+	buf := make([]float64, r*c)
 
-	for y := 0; y < 28; y++ {
-		for x := 0; x < 28; x++ {
-			s := GetX(data, x, y)
-			AddHoge(buf, s, x, y)
+	for y := 0; y < rows; y++ {
+		for x := 0; x < cols; x++ {
+			buf[(x/4)+(y/4)*c] += data[x+y*cols]
 		}
 	}
-	for y := 0; y < 7; y++ {
-		for x := 0; x < 7; x++ {
-			if GetHoge(buf, x, y) > 5 {
+	for y := 0; y < r; y++ {
+		for x := 0; x < c; x++ {
+			if buf[x+c*y] > 5 {
 				w.WriteString("x")
 			} else {
 				w.WriteString(".")
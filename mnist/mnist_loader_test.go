@@ -3,6 +3,8 @@ package mnist
 import (
 	"github.com/gonum/matrix/mat64"
 	"testing"
+
+	"github.com/ajiyoshi/gocnn/dataset"
 )
 
 func TestTrainBuffer(t *testing.T) {
@@ -68,3 +70,47 @@ func TestLabelAsNum(t *testing.T) {
 		}
 	}
 }
+
+func TestTrainBufferDataset(t *testing.T) {
+	d := &dataset.Memory{
+		Features: [][]float64{
+			{1, 0, 0, 0, 0, 0, 0, 0},
+			{0, 1, 0, 0, 0, 0, 0, 0},
+			{0, 0, 1, 0, 0, 0, 0, 0},
+		},
+		Labels:     []int{10, 0, 11},
+		Dims:       []int{1, 2, 4},
+		NumClasses: 12,
+	}
+	buf := NewDatasetBuffer(2, d)
+	if err := buf.Read(d, []int{2, 0}); err != nil {
+		t.Fatal(err)
+	}
+	mx, mt := buf.Bake()
+
+	X := mat64.NewDense(2, 8, nil)
+	X.Set(0, 2, 1)
+	X.Set(1, 0, 1)
+	T := mat64.NewDense(2, 12, nil)
+	T.Set(0, 11, 1)
+	T.Set(1, 10, 1)
+	if !mat64.Equal(mx, X) {
+		t.Fatalf("expect %v but got %v", mat64.Formatted(X), mat64.Formatted(mx))
+	}
+	if !mat64.Equal(mt, T) {
+		t.Fatalf("expect %v but got %v", mat64.Formatted(T), mat64.Formatted(mt))
+	}
+}
+
+func TestXToStringSize(t *testing.T) {
+	data := make([]float64, 8*12)
+	for y := 4; y < 8; y++ {
+		for x := 0; x < 4; x++ {
+			data[x+y*12] = 1
+		}
+	}
+	expect := "...\nx..\n"
+	if actual := XToStringSize(data, 8, 12); actual != expect {
+		t.Fatalf("expect %q but got %q", expect, actual)
+	}
+}
@@ -0,0 +1,302 @@
+package mnist
+
+import (
+	"compress/gzip"
+	"encoding/binary"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writeIdx writes an IDX file of the magic number, the dimensions and data,
+// compressed if path ends with .gz.
+func writeIdx(t *testing.T, path string, magic int32, dims []int32, data []byte) {
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	var w io.Writer = f
+	if strings.HasSuffix(path, ".gz") {
+		z := gzip.NewWriter(f)
+		defer z.Close()
+		w = z
+	}
+	binary.Write(w, binary.BigEndian, magic)
+	binary.Write(w, binary.BigEndian, dims)
+	w.Write(data)
+}
+
+func tempDir(t *testing.T) string {
+	dir, err := ioutil.TempDir("", "mnist")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return dir
+}
+
+func TestOpenVariant(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+
+	// 2 images of 2x3 stored column by column
+	image, label := EMNISTLetters.Paths(dir, true)
+	writeIdx(t, image, ImageMagic, []int32{2, 2, 3}, []byte{
+		1, 4, 2, 5, 3, 6,
+		0, 0, 0, 0, 0, 255,
+	})
+	writeIdx(t, label, LabelMagic, []int32{2}, []byte{1, 26})
+
+	m, err := EMNISTLetters.Open(dir, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer m.Close()
+
+	if m.Classes() != 26 {
+		t.Fatalf("expect 26 but got %d", m.Classes())
+	}
+	img, l, err := m.At(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expect := []byte{1, 2, 3, 4, 5, 6}; string(img) != string(expect) {
+		t.Fatalf("expect %v but got %v", expect, img)
+	}
+	if l != 1 {
+		t.Fatalf("expect 1 but got %d", l)
+	}
+	x, label1 := m.Get(1)
+	if x[5] != 1 || label1 != 25 || m.ClassName(label1) != "Z" {
+		t.Fatalf("expect (1, 25, Z) but got (%v, %d, %s)", x[5], label1, m.ClassName(label1))
+	}
+	buf := make([]float64, 6)
+	if l, err := m.Read(0, buf); err != nil || l != 0 || buf[3] != 4.0/255 {
+		t.Fatalf("expect (0, %v) but got (%d, %v, %v)", 4.0/255, l, buf[3], err)
+	}
+}
+
+func TestVariantClasses(t *testing.T) {
+	cases := []struct {
+		variant *Variant
+		classes int
+		name    string
+	}{
+		{MNIST, 10, "9"},
+		{FashionMNIST, 10, "Ankle boot"},
+		{KMNIST, 10, "を"},
+		{EMNISTByClass, 62, "z"},
+		{EMNISTByMerge, 47, "t"},
+		{EMNISTBalanced, 47, "t"},
+		{EMNISTLetters, 26, "Z"},
+		{EMNISTDigits, 10, "9"},
+	}
+	for _, c := range cases {
+		if n := len(c.variant.Classes); n != c.classes {
+			t.Fatalf("%s expect %d but got %d", c.variant.Name, c.classes, n)
+		}
+		if name := c.variant.Classes[c.classes-1]; name != c.name {
+			t.Fatalf("%s expect %s but got %s", c.variant.Name, c.name, name)
+		}
+		if v, err := LookupVariant(c.variant.Name); err != nil || v != c.variant {
+			t.Fatalf("%s expect %v but got %v, %v", c.variant.Name, c.variant, v, err)
+		}
+	}
+	if _, err := LookupVariant("cifar"); err == nil {
+		t.Fatalf("unknown dataset should be an error")
+	}
+}
+
+func TestOpenBadFile(t *testing.T) {
+	cases := []struct {
+		title      string
+		imageMagic int32
+		images     []int32
+		labelMagic int32
+		labels     []byte
+		err        string
+	}{
+		{"not idx", 0x01020803, []int32{2, 1, 1}, LabelMagic, []byte{0, 1}, "not an IDX"},
+		{"int type", 0x00000c03, []int32{2, 1, 1}, LabelMagic, []byte{0, 1}, "data type"},
+		{"labels as images", LabelMagic, []int32{2, 1, 1}, LabelMagic, []byte{0, 1}, "dimensions"},
+		{"truncated", ImageMagic, []int32{3, 1, 1}, LabelMagic, []byte{0, 1}, "truncated IDX file"},
+		{"count", ImageMagic, []int32{1, 1, 1}, LabelMagic, []byte{0, 1}, "1 images but 2 labels"},
+		{"label", ImageMagic, []int32{2, 1, 1}, LabelMagic, []byte{0, 10}, "should be in [0, 10)"},
+	}
+	for _, c := range cases {
+		dir := tempDir(t)
+		image, label := filepath.Join(dir, "images"), filepath.Join(dir, "labels")
+		writeIdx(t, image, c.imageMagic, c.images, []byte{0, 0})
+		writeIdx(t, label, c.labelMagic, []int32{int32(len(c.labels))}, c.labels)
+		m, err := NewMnist(image, label)
+		os.RemoveAll(dir)
+		if err == nil {
+			m.Close()
+			t.Fatalf("%s should be an error", c.title)
+		}
+		if !strings.Contains(err.Error(), c.err) {
+			t.Fatalf("%s expect %q but got %q", c.title, c.err, err.Error())
+		}
+		if _, ok := err.(*FormatError); !ok {
+			t.Fatalf("%s expect *FormatError but got %T", c.title, err)
+		}
+	}
+}
+
+func TestOpenGzip(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+
+	images := []byte{
+		0, 1, 2, 3,
+		4, 5, 6, 7,
+		8, 9, 10, 11,
+	}
+	labels := []byte{3, 1, 4}
+	image, label := MNIST.Paths(dir, false)
+	writeIdx(t, image, ImageMagic, []int32{3, 2, 2}, images)
+	writeIdx(t, label, LabelMagic, []int32{3}, labels)
+	gzImage, gzLabel := filepath.Join(dir, "train-images-idx3-ubyte.gz"), filepath.Join(dir, "train-labels-idx1-ubyte.gz")
+	writeIdx(t, gzImage, ImageMagic, []int32{3, 2, 2}, images)
+	writeIdx(t, gzLabel, LabelMagic, []int32{3}, labels)
+
+	idx, err := MNIST.Open(dir, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer idx.Close()
+	gz, err := MNIST.Open(dir, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer gz.Close()
+
+	if gz.Len() != 3 {
+		t.Fatalf("expect 3 but got %d", gz.Len())
+	}
+	for i := 0; i < 3; i++ {
+		x, l, err := idx.At(i)
+		if err != nil {
+			t.Fatal(err)
+		}
+		y, m, err := gz.At(i)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(x) != string(y) || l != m {
+			t.Fatalf("expect (%v, %d) but got (%v, %d)", x, l, y, m)
+		}
+	}
+}
+
+func TestOpenTruncatedHeader(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "images")
+	if err := ioutil.WriteFile(path, []byte{0, 0, 8, 3, 0, 0}, 0644); err != nil {
+		t.Fatal(err)
+	}
+	_, err := NewMnistImage(path)
+	e, ok := err.(*FormatError)
+	if !ok || e.Path != path || !strings.Contains(e.Msg, "too short") {
+		t.Fatalf("expect *FormatError of %s but got %#v", path, err)
+	}
+}
+
+func TestOpenBadImageSize(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "images")
+	cases := []struct {
+		title string
+		dims  []int32
+		msg   string
+	}{
+		{"no row", []int32{0, 0, 28}, "should be positive"},
+		{"no column", []int32{0, 28, 0}, "should be positive"},
+		{"overflow", []int32{0, 0x7fffffff, 0x7fffffff}, "larger than the file"},
+		{"larger than the file", []int32{0, 4, 5}, "larger than the file"},
+	}
+	for _, c := range cases {
+		writeIdx(t, path, ImageMagic, c.dims, nil)
+		_, err := NewMnistImage(path)
+		e, ok := err.(*FormatError)
+		if !ok || e.Path != path || !strings.Contains(e.Msg, c.msg) {
+			t.Fatalf("%s expect *FormatError %q but got %v", c.title, c.msg, err)
+		}
+	}
+}
+
+func TestReadOutOfRange(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+	image, label := MNIST.Paths(dir, true)
+	writeIdx(t, image, ImageMagic, []int32{2, 1, 2}, []byte{1, 2, 3, 4})
+	writeIdx(t, label, LabelMagic, []int32{2}, []byte{5, 6})
+	m, err := MNIST.Open(dir, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer m.Close()
+
+	cases := []struct {
+		title string
+		err   func(i int) error
+	}{
+		{"Jump", m.Jump},
+		{"At", func(i int) error {
+			_, _, err := m.At(i)
+			return err
+		}},
+		{"Read", func(i int) error {
+			_, err := m.Read(i, make([]float64, 2))
+			return err
+		}},
+		{"Image.ReadAt", func(i int) error {
+			return m.Images.ReadAt(i, make([]byte, 2))
+		}},
+		{"Label.At", func(i int) error {
+			_, err := m.Labels.At(i)
+			return err
+		}},
+		{"ReadLabel", func(i int) error {
+			_, err := m.ReadLabel(i)
+			return err
+		}},
+	}
+	for _, c := range cases {
+		if err := c.err(1); err != nil {
+			t.Fatalf("%s(1) should succeed but got %v", c.title, err)
+		}
+		for _, i := range []int{-1, 2} {
+			err := c.err(i)
+			expect := &IndexError{Index: i, Num: 2}
+			if e, ok := err.(*IndexError); !ok || *e != *expect {
+				t.Fatalf("%s(%d) expect %v but got %v", c.title, i, expect, err)
+			}
+		}
+	}
+
+	defer func() {
+		if _, ok := recover().(*IndexError); !ok {
+			t.Fatalf("Get should panic with *IndexError")
+		}
+	}()
+	m.Get(2)
+}
+
+func TestMustRead(t *testing.T) {
+	r := strings.NewReader("abc")
+	buf := make([]byte, 2)
+	if err := MustRead(r, 1, buf); err != nil || string(buf) != "bc" {
+		t.Fatalf("expect bc but got %q, %v", buf, err)
+	}
+	err := MustRead(r, 2, buf)
+	expect := &TruncatedError{Offset: 2, Want: 2, Got: 1}
+	if e, ok := err.(*TruncatedError); !ok || *e != *expect {
+		t.Fatalf("expect %v but got %v", expect, err)
+	}
+}
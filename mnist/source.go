@@ -0,0 +1,69 @@
+package mnist
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"golang.org/x/exp/mmap"
+)
+
+/*
+source IDX ファイルの中身。非圧縮のファイルは mmap し、.gz のファイルはメモリに展開する
+*/
+type source interface {
+	io.ReaderAt
+	At(i int) byte
+	Len() int
+	Close() error
+}
+
+var (
+	_ source = (*mmap.ReaderAt)(nil)
+	_ source = (*memory)(nil)
+)
+
+/*
+openSource path を開く。.gz で終わるパスは gzip として展開する
+*/
+func openSource(path string) (source, error) {
+	if !strings.HasSuffix(path, ".gz") {
+		return mmap.Open(path)
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	r, err := gzip.NewReader(f)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	return &memory{Reader: bytes.NewReader(data), data: data}, nil
+}
+
+/*
+memory メモリ上に展開した IDX ファイル
+*/
+type memory struct {
+	*bytes.Reader
+	data []byte
+}
+
+func (m *memory) At(i int) byte {
+	return m.data[i]
+}
+func (m *memory) Len() int {
+	return len(m.data)
+}
+func (m *memory) Close() error {
+	return nil
+}
@@ -0,0 +1,121 @@
+package mnist
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+/*
+Variant MNIST と同じ IDX 形式のデータセットの種類。ファイル名は Train や Test に "-images-idx3-ubyte.idx" などを付けたもの
+*/
+type Variant struct {
+	Name        string
+	Train       string
+	Test        string
+	Classes     []string
+	LabelOffset int
+	Transposed  bool
+}
+
+var (
+	digits = strings.Split("0123456789", "")
+	upper  = strings.Split("ABCDEFGHIJKLMNOPQRSTUVWXYZ", "")
+	lower  = strings.Split("abcdefghijklmnopqrstuvwxyz", "")
+	merged = strings.Split("abdefghnqrt", "")
+)
+
+var (
+	MNIST = &Variant{Name: "mnist", Train: "train", Test: "t10k", Classes: digits}
+
+	FashionMNIST = &Variant{Name: "fashion", Train: "train", Test: "t10k", Classes: []string{
+		"T-shirt/top", "Trouser", "Pullover", "Dress", "Coat",
+		"Sandal", "Shirt", "Sneaker", "Bag", "Ankle boot",
+	}}
+
+	KMNIST = &Variant{Name: "kmnist", Train: "train", Test: "t10k", Classes: []string{
+		"お", "き", "す", "つ", "な", "は", "ま", "や", "れ", "を",
+	}}
+
+	// EMNIST のイメージは転置して保存されている
+	EMNISTByClass  = emnist("byclass", concat(digits, upper, lower), 0)
+	EMNISTByMerge  = emnist("bymerge", concat(digits, upper, merged), 0)
+	EMNISTBalanced = emnist("balanced", concat(digits, upper, merged), 0)
+	EMNISTLetters  = emnist("letters", upper, 1)
+	EMNISTDigits   = emnist("digits", digits, 0)
+	EMNISTMNIST    = emnist("mnist", digits, 0)
+
+	Variants = []*Variant{
+		MNIST, FashionMNIST, KMNIST,
+		EMNISTByClass, EMNISTByMerge, EMNISTBalanced, EMNISTLetters, EMNISTDigits, EMNISTMNIST,
+	}
+)
+
+func concat(xs ...[]string) []string {
+	var ret []string
+	for _, x := range xs {
+		ret = append(ret, x...)
+	}
+	return ret
+}
+
+func emnist(split string, classes []string, offset int) *Variant {
+	return &Variant{
+		Name:        "emnist-" + split,
+		Train:       "emnist-" + split + "-train",
+		Test:        "emnist-" + split + "-test",
+		Classes:     classes,
+		LabelOffset: offset,
+		Transposed:  true,
+	}
+}
+
+/*
+LookupVariant 名前から Variant を探す
+*/
+func LookupVariant(name string) (*Variant, error) {
+	for _, v := range Variants {
+		if v.Name == name {
+			return v, nil
+		}
+	}
+	names := make([]string, len(Variants))
+	for i, v := range Variants {
+		names[i] = v.Name
+	}
+	return nil, fmt.Errorf("unknown dataset %q (one of %s)", name, strings.Join(names, ", "))
+}
+
+/*
+Paths dir にある学習用(train)またはテスト用のイメージとラベルのファイル名
+*/
+func (v *Variant) Paths(dir string, train bool) (image, label string) {
+	name := v.Test
+	if train {
+		name = v.Train
+	}
+	return filepath.Join(dir, name+"-images-idx3-ubyte.idx"), filepath.Join(dir, name+"-labels-idx1-ubyte.idx")
+}
+
+/*
+Open dir にある学習用(train)またはテスト用のデータセットを開く。展開した .idx がなければダウンロードしたままの .gz を開く
+*/
+func (v *Variant) Open(dir string, train bool) (*Mnist, error) {
+	image, label := v.Paths(dir, train)
+	return Open(v, orGzip(image), orGzip(label))
+}
+
+/*
+orGzip path がなく、拡張子を .gz に替えたファイルがあればそのパス
+*/
+func orGzip(path string) string {
+	if _, err := os.Stat(path); err == nil {
+		return path
+	}
+	gz := strings.TrimSuffix(path, ".idx") + ".gz"
+	if _, err := os.Stat(gz); err == nil {
+		return gz
+	}
+	return path
+}
@@ -198,7 +198,7 @@ func (x *ndArray) Clone() Array {
 	for i := x.Iterator(); i.OK(); i.Next() {
 		index := i.Index()
 		v := x.Get(index...)
-		x.Set(v, index...)
+		ret.Set(v, index...)
 	}
 	return ret
 }
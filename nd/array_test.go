@@ -169,3 +169,32 @@ func TestArrayTranspose(t *testing.T) {
 		}
 	}
 }
+
+func TestArrayClone(t *testing.T) {
+	a := NewArray(NewShape(2, 3), []float64{
+		1, 2, 3,
+		4, 5, 6,
+	})
+	cases := []struct {
+		msg    string
+		input  Array
+		expect Array
+	}{
+		{msg: "clone", input: a, expect: a},
+		{msg: "clone of transposed", input: a.Transpose(1, 0), expect: NewArray(NewShape(3, 2), []float64{
+			1, 4,
+			2, 5,
+			3, 6,
+		})},
+	}
+	for _, c := range cases {
+		actual := c.input.Clone()
+		if !actual.Equals(c.expect) {
+			t.Fatalf("(%s) expect %v but actual %v", c.msg, c.expect, actual)
+		}
+		actual.Set(100, 0, 0)
+		if c.input.Get(0, 0) != 1 {
+			t.Fatalf("(%s) should not share storage", c.msg)
+		}
+	}
+}
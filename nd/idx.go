@@ -0,0 +1,250 @@
+package nd
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+)
+
+// IdxType is the data type code, the third byte of the magic number of an
+// IDX file.
+type IdxType byte
+
+const (
+	IdxUbyte  IdxType = 0x08
+	IdxByte   IdxType = 0x09
+	IdxShort  IdxType = 0x0B
+	IdxInt    IdxType = 0x0C
+	IdxFloat  IdxType = 0x0D
+	IdxDouble IdxType = 0x0E
+)
+
+// Size is the number of bytes of a value, 0 for an unknown type.
+func (t IdxType) Size() int {
+	switch t {
+	case IdxUbyte, IdxByte:
+		return 1
+	case IdxShort:
+		return 2
+	case IdxInt, IdxFloat:
+		return 4
+	case IdxDouble:
+		return 8
+	}
+	return 0
+}
+
+func (t IdxType) String() string {
+	switch t {
+	case IdxUbyte:
+		return "ubyte"
+	case IdxByte:
+		return "byte"
+	case IdxShort:
+		return "short"
+	case IdxInt:
+		return "int"
+	case IdxFloat:
+		return "float"
+	case IdxDouble:
+		return "double"
+	}
+	return fmt.Sprintf("0x%02x", byte(t))
+}
+
+// IdxHeader is the magic number and the dimensions of an IDX file. The
+// values follow in big endian, the last dimension changing fastest.
+type IdxHeader struct {
+	Type  IdxType
+	Shape Shape
+}
+
+// ReadIdxHeader reads and checks the header of an IDX file.
+func ReadIdxHeader(r io.Reader) (*IdxHeader, error) {
+	var magic [4]byte
+	if _, err := io.ReadFull(r, magic[:]); err != nil {
+		return nil, err
+	}
+	if magic[0] != 0 || magic[1] != 0 {
+		return nil, fmt.Errorf("not an IDX file (magic number 0x%x)", magic)
+	}
+	h := &IdxHeader{Type: IdxType(magic[2]), Shape: make(Shape, magic[3])}
+	if h.Type.Size() == 0 {
+		return nil, fmt.Errorf("unknown IDX data type %s", h.Type)
+	}
+	for i := range h.Shape {
+		var d uint32
+		if err := binary.Read(r, binary.BigEndian, &d); err != nil {
+			return nil, err
+		}
+		if d > math.MaxInt32 {
+			return nil, fmt.Errorf("bad IDX dimension %d", d)
+		}
+		h.Shape[i] = int(d)
+	}
+	if _, err := h.DataLen(); err != nil {
+		return nil, err
+	}
+	return h, nil
+}
+
+// Len is the number of bytes of the header.
+func (h *IdxHeader) Len() int {
+	return 4 + 4*len(h.Shape)
+}
+
+// maxInt is the largest int, the limit of the number of bytes of the values.
+const maxInt = int(^uint(0) >> 1)
+
+// DataLen is the number of bytes of the values following the header. It
+// fails if the number does not fit in an int.
+func (h *IdxHeader) DataLen() (int64, error) {
+	n := h.Type.Size()
+	for _, d := range h.Shape {
+		if d == 0 {
+			return 0, nil
+		}
+	}
+	for _, d := range h.Shape {
+		if d < 0 || n > maxInt/d {
+			return 0, fmt.Errorf("IDX data of %v %s is too large", h.Shape, h.Type)
+		}
+		n *= d
+	}
+	return int64(n), nil
+}
+
+func (h *IdxHeader) Write(w io.Writer) error {
+	if h.Type.Size() == 0 {
+		return fmt.Errorf("unknown IDX data type %s", h.Type)
+	}
+	if len(h.Shape) > 255 {
+		return fmt.Errorf("IDX supports at most 255 dimensions but got %d", len(h.Shape))
+	}
+	if _, err := h.DataLen(); err != nil {
+		return err
+	}
+	buf := make([]byte, h.Len())
+	buf[2], buf[3] = byte(h.Type), byte(len(h.Shape))
+	for i, d := range h.Shape {
+		binary.BigEndian.PutUint32(buf[4+4*i:], uint32(d))
+	}
+	_, err := w.Write(buf)
+	return err
+}
+
+// Decode converts the values of raw to x.
+func (h *IdxHeader) Decode(raw []byte, x []float64) {
+	size := h.Type.Size()
+	for i := range x {
+		b := raw[i*size:]
+		switch h.Type {
+		case IdxUbyte:
+			x[i] = float64(b[0])
+		case IdxByte:
+			x[i] = float64(int8(b[0]))
+		case IdxShort:
+			x[i] = float64(int16(binary.BigEndian.Uint16(b)))
+		case IdxInt:
+			x[i] = float64(int32(binary.BigEndian.Uint32(b)))
+		case IdxFloat:
+			x[i] = float64(math.Float32frombits(binary.BigEndian.Uint32(b)))
+		case IdxDouble:
+			x[i] = math.Float64frombits(binary.BigEndian.Uint64(b))
+		}
+	}
+}
+
+// Encode converts x to the values of raw. It fails on a value which the
+// type cannot hold exactly, like 0.5 or 256 as ubyte.
+func (h *IdxHeader) Encode(x []float64, raw []byte) error {
+	size := h.Type.Size()
+	for i, v := range x {
+		b := raw[i*size:]
+		if err := h.check(v); err != nil {
+			return fmt.Errorf("value %d: %v", i, err)
+		}
+		switch h.Type {
+		case IdxUbyte:
+			b[0] = byte(v)
+		case IdxByte:
+			b[0] = byte(int8(v))
+		case IdxShort:
+			binary.BigEndian.PutUint16(b, uint16(int16(v)))
+		case IdxInt:
+			binary.BigEndian.PutUint32(b, uint32(int32(v)))
+		case IdxFloat:
+			binary.BigEndian.PutUint32(b, math.Float32bits(float32(v)))
+		case IdxDouble:
+			binary.BigEndian.PutUint64(b, math.Float64bits(v))
+		}
+	}
+	return nil
+}
+
+func (h *IdxHeader) check(v float64) error {
+	var min, max float64
+	switch h.Type {
+	case IdxUbyte:
+		min, max = 0, math.MaxUint8
+	case IdxByte:
+		min, max = math.MinInt8, math.MaxInt8
+	case IdxShort:
+		min, max = math.MinInt16, math.MaxInt16
+	case IdxInt:
+		min, max = math.MinInt32, math.MaxInt32
+	case IdxFloat:
+		if float64(float32(v)) != v && !math.IsNaN(v) {
+			return fmt.Errorf("%v cannot be stored as %s", v, h.Type)
+		}
+		return nil
+	default:
+		return nil
+	}
+	if v != math.Trunc(v) || v < min || v > max {
+		return fmt.Errorf("%v cannot be stored as %s", v, h.Type)
+	}
+	return nil
+}
+
+// NewIdxArray reads an IDX file into an Array of its shape. The values are
+// read into a buffer growing as they arrive, so that a short file fails
+// before allocating what its header claims.
+func NewIdxArray(r io.Reader) (Array, error) {
+	h, err := ReadIdxHeader(r)
+	if err != nil {
+		return nil, err
+	}
+	n, _ := h.DataLen()
+	var buf bytes.Buffer
+	if _, err := io.CopyN(&buf, r, n); err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return nil, fmt.Errorf("IDX data of %v %s: %v", h.Shape, h.Type, err)
+	}
+	ret := Zeros(h.Shape)
+	h.Decode(buf.Bytes(), ret.data)
+	return ret, nil
+}
+
+// WriteIdx writes x to w as an IDX file of values of type t.
+func WriteIdx(w io.Writer, x Array, t IdxType) error {
+	h := &IdxHeader{Type: t, Shape: x.Shape()}
+	if err := h.Write(w); err != nil {
+		return err
+	}
+	data := make([]float64, 0, x.Shape().Size())
+	for i := x.Iterator(); i.OK(); i.Next() {
+		data = append(data, x.Get(i.Index()...))
+	}
+	n, _ := h.DataLen()
+	raw := make([]byte, n)
+	if err := h.Encode(data, raw); err != nil {
+		return err
+	}
+	_, err := w.Write(raw)
+	return err
+}
@@ -0,0 +1,165 @@
+package nd
+
+import (
+	"bytes"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestNewIdxArray(t *testing.T) {
+	cases := []struct {
+		msg    string
+		path   string
+		expect Array
+	}{
+		{
+			msg:  "short (2, 3)",
+			path: "./t/short_2_3.idx",
+			expect: NewArray(NewShape(2, 3), []float64{
+				1, -2, 3,
+				-4, 5, -300,
+			}),
+		},
+		{
+			msg:  "double (2, 1, 2)",
+			path: "./t/double_2_1_2.idx",
+			expect: NewArray(NewShape(2, 1, 2), []float64{
+				0.5, -1.25,
+				3, 1e-3,
+			}),
+		},
+	}
+	for _, c := range cases {
+		f, err := os.Open(c.path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		actual, err := NewIdxArray(f)
+		f.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !actual.EqualApprox(c.expect, 1e-12) {
+			t.Fatalf("(%s) expect %s got %s", c.msg, c.expect, actual)
+		}
+	}
+}
+
+func TestWriteIdx(t *testing.T) {
+	x := NewArray(NewShape(2, 3), []float64{
+		0, 1, 2,
+		3, 4, 127,
+	})
+	cases := []struct {
+		t    IdxType
+		x    Array
+		size int
+	}{
+		{t: IdxUbyte, x: x, size: 12 + 6},
+		{t: IdxByte, x: x.Clone().Scale(-1), size: 12 + 6},
+		{t: IdxShort, x: x.Clone().Scale(100), size: 12 + 12},
+		{t: IdxInt, x: x.Clone().Scale(-1e6), size: 12 + 24},
+		{t: IdxFloat, x: x.Clone().Scale(0.5), size: 12 + 24},
+		{t: IdxDouble, x: x.Clone().Scale(0.1), size: 12 + 48},
+		{t: IdxUbyte, x: x.Transpose(1, 0), size: 12 + 6},
+	}
+	for _, c := range cases {
+		var buf bytes.Buffer
+		if err := WriteIdx(&buf, c.x, c.t); err != nil {
+			t.Fatalf("%s: %v", c.t, err)
+		}
+		if buf.Len() != c.size {
+			t.Fatalf("%s expect %d bytes but got %d", c.t, c.size, buf.Len())
+		}
+		h, err := ReadIdxHeader(bytes.NewReader(buf.Bytes()))
+		if err != nil || h.Type != c.t {
+			t.Fatalf("%s expect %s but got %v, %v", c.t, c.t, h, err)
+		}
+		actual, err := NewIdxArray(&buf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !actual.EqualApprox(c.x, 1e-12) {
+			t.Fatalf("%s expect %s but got %s", c.t, c.x, actual)
+		}
+	}
+}
+
+func TestIdxError(t *testing.T) {
+	x := NewArray(NewShape(2), []float64{1, 0.5})
+	cases := []struct {
+		msg  string
+		err  func() error
+		want string
+	}{
+		{
+			msg: "not idx",
+			err: func() error {
+				_, err := NewIdxArray(bytes.NewReader([]byte{1, 2, 8, 1, 0, 0, 0, 0}))
+				return err
+			},
+			want: "not an IDX",
+		},
+		{
+			msg: "unknown type",
+			err: func() error {
+				_, err := NewIdxArray(bytes.NewReader([]byte{0, 0, 7, 1, 0, 0, 0, 0}))
+				return err
+			},
+			want: "unknown IDX data type 0x07",
+		},
+		{
+			msg: "truncated",
+			err: func() error {
+				_, err := NewIdxArray(bytes.NewReader([]byte{0, 0, 8, 1, 0, 0, 0, 3, 1, 2}))
+				return err
+			},
+			want: "IDX data of [3] ubyte",
+		},
+		{
+			msg: "too large",
+			err: func() error {
+				_, err := NewIdxArray(bytes.NewReader([]byte{0, 0, 8, 3,
+					0x7f, 0xff, 0xff, 0xff, 0x7f, 0xff, 0xff, 0xff, 0x7f, 0xff, 0xff, 0xff}))
+				return err
+			},
+			want: "is too large",
+		},
+		{
+			msg: "truncated large",
+			err: func() error {
+				_, err := NewIdxArray(bytes.NewReader([]byte{0, 0, 0x0E, 2, 0, 0x10, 0, 0, 0, 0, 0x10, 0, 1, 2}))
+				return err
+			},
+			want: "unexpected EOF",
+		},
+		{
+			msg: "double as float",
+			err: func() error {
+				return WriteIdx(&bytes.Buffer{}, NewArray(NewShape(1), []float64{0.1}), IdxFloat)
+			},
+			want: "0.1 cannot be stored as float",
+		},
+		{
+			msg: "fraction as ubyte",
+			err: func() error {
+				return WriteIdx(&bytes.Buffer{}, x, IdxUbyte)
+			},
+			want: "value 1: 0.5 cannot be stored as ubyte",
+		},
+		{
+			msg: "overflow",
+			err: func() error {
+				return WriteIdx(&bytes.Buffer{}, NewArray(NewShape(1), []float64{128}), IdxByte)
+			},
+			want: "128 cannot be stored as byte",
+		},
+	}
+	for _, c := range cases {
+		err := c.err()
+		if err == nil || !strings.Contains(err.Error(), c.want) {
+			t.Fatalf("%s expect %q but got %v", c.msg, c.want, err)
+		}
+	}
+}
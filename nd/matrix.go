@@ -48,3 +48,36 @@ func (m *ndArrayMatrix) Dims() (r int, c int) {
 func (m *ndArrayMatrix) T() mat.Matrix {
 	return matrix.NewTransposeMutable(m)
 }
+
+// NewDenseArray is an Array of shape (row, col) sharing the storage of m.
+func NewDenseArray(m *mat.Dense) *ndArray {
+	r, c := m.Dims()
+	raw := m.RawMatrix()
+	if raw.Stride != c {
+		panic("matrix should be contiguous")
+	}
+	return NewArray(NewShape(r, c), raw.Data[:r*c])
+}
+
+// NewVectorArray is an Array of shape (n) sharing the storage of v.
+func NewVectorArray(v *mat.Vector) *ndArray {
+	n := v.Len()
+	raw := v.RawVector()
+	if raw.Inc != 1 {
+		panic("vector should be contiguous")
+	}
+	return NewArray(NewShape(n), raw.Data[:n])
+}
+
+// Contiguous returns the storage of x in row-major order if x is not a view
+// with its own indexing, such as a transposed or sliced Array.
+func Contiguous(x Array) ([]float64, bool) {
+	a, ok := x.(*ndArray)
+	if !ok {
+		return nil, false
+	}
+	if _, ok := a.index.(*NormalIndexer); !ok {
+		return nil, false
+	}
+	return a.data[:a.shape.Size()], true
+}
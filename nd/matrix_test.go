@@ -67,3 +67,31 @@ func TestArrayMatrix(t *testing.T) {
 		}
 	}
 }
+
+func TestDenseArray(t *testing.T) {
+	m := mat.NewDense(2, 3, []float64{
+		1, 2, 3,
+		4, 5, 6,
+	})
+	a := NewDenseArray(m)
+	if !a.Shape().Equals(NewShape(2, 3)) {
+		t.Fatalf("expect (2, 3) but got %v", a.Shape())
+	}
+	a.Set(10, 1, 2)
+	if m.At(1, 2) != 10 {
+		t.Fatalf("array should share storage with %v", m)
+	}
+
+	v := mat.NewVector(2, []float64{1, 2})
+	NewVectorArray(v).Scale(2)
+	if v.At(1, 0) != 4 {
+		t.Fatalf("array should share storage with %v", v)
+	}
+
+	if data, ok := Contiguous(a); !ok || len(data) != 6 {
+		t.Fatalf("expect contiguous but got %v, %v", data, ok)
+	}
+	if _, ok := Contiguous(a.Transpose(1, 0)); ok {
+		t.Fatalf("transposed array should not be contiguous")
+	}
+}
@@ -24,3 +24,23 @@ dump("float_3_2.mp", np.array(
     )
 
 
+
+
+def dump_idx(path, x, code):
+    with open(path, 'wb') as f:
+        f.write(bytes([0, 0, code, x.ndim]))
+        f.write(np.array(x.shape, dtype='>i4').tobytes())
+        f.write(x.tobytes())
+
+
+dump_idx("short_2_3.idx", np.array(
+    [
+        [1, -2, 3],
+        [-4, 5, -300],
+        ], dtype='>i2'), 0x0B)
+
+dump_idx("double_2_1_2.idx", np.array(
+    [
+        [[0.5, -1.25]],
+        [[3.0, 1e-3]],
+        ], dtype='>f8'), 0x0E)
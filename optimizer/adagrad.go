@@ -0,0 +1,96 @@
+package optimizer
+
+import (
+	"math"
+)
+
+var (
+	_ Optimizer = (*AdaGrad)(nil)
+	_ Optimizer = (*RMSProp)(nil)
+	_ Optimizer = (*AdaDelta)(nil)
+)
+
+type AdaGrad struct {
+	Lr float64
+	elementwise
+}
+
+func NewAdaGradFactory(lr float64) OptimizerFactory {
+	return func() Optimizer {
+		return NewAdaGrad(lr)
+	}
+}
+
+func NewAdaGrad(lr float64) *AdaGrad {
+	o := &AdaGrad{Lr: lr}
+	o.rule = o
+	return o
+}
+
+func (o *AdaGrad) update(s *slot, param, grad []float64) {
+	s.init(len(param))
+	for i, g := range grad {
+		s.v[i] += square(g)
+		param[i] -= o.Lr * g / sqrt(s.v[i])
+	}
+}
+
+// RMSProp is AdaGrad with an exponential moving average of squared gradients
+// instead of their sum.
+type RMSProp struct {
+	Lr    float64
+	Decay float64
+	elementwise
+}
+
+func NewRMSPropFactory(lr, decay float64) OptimizerFactory {
+	return func() Optimizer {
+		return NewRMSProp(lr, decay)
+	}
+}
+
+func NewRMSProp(lr, decay float64) *RMSProp {
+	o := &RMSProp{Lr: lr, Decay: decay}
+	o.rule = o
+	return o
+}
+
+func (o *RMSProp) update(s *slot, param, grad []float64) {
+	s.init(len(param))
+	for i, g := range grad {
+		s.v[i] = o.Decay*s.v[i] + (1-o.Decay)*square(g)
+		param[i] -= o.Lr * g / sqrt(s.v[i])
+	}
+}
+
+// AdaDelta scales each step by the ratio of the RMS of past updates to the
+// RMS of past gradients. Lr is 1 in the original paper.
+type AdaDelta struct {
+	Lr  float64
+	Rho float64
+	Eps float64
+	elementwise
+}
+
+func NewAdaDeltaFactory(lr, rho float64) OptimizerFactory {
+	return func() Optimizer {
+		return NewAdaDelta(lr, rho)
+	}
+}
+
+func NewAdaDelta(lr, rho float64) *AdaDelta {
+	o := &AdaDelta{Lr: lr, Rho: rho, Eps: 1e-6}
+	o.rule = o
+	return o
+}
+
+func (o *AdaDelta) update(s *slot, param, grad []float64) {
+	s.init(len(param))
+	// s.v : E[g^2], s.m : E[dx^2]
+	for i, g := range grad {
+		s.v[i] = o.Rho*s.v[i] + (1-o.Rho)*square(g)
+		dx := math.Sqrt(s.m[i]+o.Eps) / math.Sqrt(s.v[i]+o.Eps) * g
+		s.m[i] = o.Rho*s.m[i] + (1-o.Rho)*square(dx)
+		param[i] -= o.Lr * dx
+	}
+}
@@ -0,0 +1,51 @@
+package optimizer
+
+import (
+	"math"
+
+	mat "github.com/gonum/matrix/mat64"
+
+	"github.com/ajiyoshi/gocnn/nd"
+)
+
+// ClipByNorm rescales the gradients so that their L2 norm does not exceed
+// MaxNorm. Given all the parameters at once by a NetworkOptimizer, it clips
+// them by their global norm with ClipByGlobalNorm; updated one array at a
+// time, each gradient is clipped by its own norm.
+type ClipByNorm struct {
+	Optimizer
+	MaxNorm float64
+}
+
+func NewClipByNorm(f OptimizerFactory, maxNorm float64) OptimizerFactory {
+	return func() Optimizer {
+		return &ClipByNorm{Optimizer: f(), MaxNorm: maxNorm}
+	}
+}
+
+func (o *ClipByNorm) UpdateWeight(param, grad *mat.Dense) {
+	var g mat.Dense
+	var sq mat.Dense
+	sq.MulElem(grad, grad)
+	g.Scale(clipScale(math.Sqrt(mat.Sum(&sq)), o.MaxNorm), grad)
+	o.Optimizer.UpdateWeight(param, &g)
+}
+func (o *ClipByNorm) UpdateWeightArray(param, grad nd.Array) {
+	g := grad.Clone()
+	ClipByGlobalNorm([]Param{{Value: param, Grad: g}}, o.MaxNorm)
+	o.Optimizer.UpdateWeightArray(param, g)
+}
+func (o *ClipByNorm) UpdateBias(param, grad *mat.Vector) {
+	g := mat.NewVector(grad.Len(), nil)
+	g.ScaleVec(clipScale(math.Sqrt(mat.Dot(grad, grad)), o.MaxNorm), grad)
+	o.Optimizer.UpdateBias(param, g)
+}
+
+// clipScale is the factor by which gradients of the given norm are scaled
+// to fit in maxNorm.
+func clipScale(norm, maxNorm float64) float64 {
+	if norm <= maxNorm || norm == 0 {
+		return 1
+	}
+	return maxNorm / norm
+}
@@ -3,6 +3,8 @@ package optimizer
 import (
 	"github.com/gonum/matrix/mat64"
 	"testing"
+
+	"github.com/ajiyoshi/gocnn/nd"
 )
 
 func TestMomemtum(t *testing.T) {
@@ -19,3 +21,61 @@ func TestMomemtum(t *testing.T) {
 
 	m.UpdateWeight(param, grad)
 }
+
+// quadratic is f(p) = sum(a[i] * (p[i] - c[i])^2 / 2) whose minimum is c.
+type quadratic struct {
+	a []float64
+	c []float64
+}
+
+func (q *quadratic) grad(p []float64) []float64 {
+	ret := make([]float64, len(p))
+	for i, x := range p {
+		ret[i] = q.a[i] * (x - q.c[i])
+	}
+	return ret
+}
+
+func TestConvergence(t *testing.T) {
+	q := &quadratic{
+		a: []float64{1, 4, 0.5, 2},
+		c: []float64{1, -2, 3, 0.5},
+	}
+	cases := []struct {
+		title string
+		f     OptimizerFactory
+		iter  int
+	}{
+		{title: "SGD", f: NewSGDFactory(0.1), iter: 500},
+		{title: "Momentum", f: NewMomentumFactory(0.05, 0.9), iter: 500},
+		{title: "Nesterov", f: NewNesterovFactory(0.05, 0.9), iter: 500},
+		{title: "AdaGrad", f: NewAdaGradFactory(0.5), iter: 2000},
+		{title: "RMSProp", f: NewRMSPropFactory(0.01, 0.9), iter: 2000},
+		{title: "AdaDelta", f: NewAdaDeltaFactory(1, 0.9), iter: 5000},
+		{title: "Adam", f: NewAdam(0.05, 0.9, 0.999), iter: 2000},
+		{title: "AdamW", f: NewAdamW(0.05, 0.9, 0.999, 0.0001), iter: 2000},
+	}
+	for _, c := range cases {
+		o := c.f()
+		n := len(q.c)
+		w := mat64.NewDense(1, n, nil)
+		b := mat64.NewVector(n, nil)
+		a := nd.Zeros(nd.NewShape(1, 1, 2, 2))
+		for i := 0; i < c.iter; i++ {
+			o.UpdateWeight(w, mat64.NewDense(1, n, q.grad(mat64.Row(nil, 0, w))))
+			o.UpdateBias(b, mat64.NewVector(n, q.grad(mat64.Col(nil, 0, b))))
+			ga := q.grad(mat64.Row(nil, 0, a.AsMatrix(1, n)))
+			o.UpdateWeightArray(a, nd.NewArray(a.Shape(), ga))
+		}
+		expect := mat64.NewDense(1, n, q.c)
+		if !mat64.EqualApprox(w, expect, 0.01) {
+			t.Fatalf("%s expect %v but got %v", c.title, q.c, mat64.Row(nil, 0, w))
+		}
+		if !mat64.EqualApprox(b.T(), w, 1e-9) {
+			t.Fatalf("%s weight %v and bias %v should be same", c.title, w, b)
+		}
+		if !mat64.EqualApprox(a.AsMatrix(1, n), w, 1e-9) {
+			t.Fatalf("%s weight %v and array %v should be same", c.title, w, a)
+		}
+	}
+}
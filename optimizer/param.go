@@ -0,0 +1,147 @@
+package optimizer
+
+import (
+	"math"
+
+	"github.com/ajiyoshi/gocnn/nd"
+)
+
+// Param is a named parameter tensor of a layer and its gradient. Both share
+// storage with the layer, so updating Value updates the layer.
+type Param struct {
+	Name  string
+	Value nd.Array
+	Grad  nd.Array
+}
+
+// Parameterized is a layer or a network exposing its parameters.
+type Parameterized interface {
+	Params() []Param
+}
+
+// ParamUpdater is an optimizer able to update any number of parameters in
+// one step. It keeps its state for each parameter by name.
+type ParamUpdater interface {
+	UpdateParams([]Param)
+}
+
+var (
+	_ ParamUpdater = (*elementwise)(nil)
+	_ ParamUpdater = (*WeightDecay)(nil)
+	_ ParamUpdater = (*DecoupledWeightDecay)(nil)
+	_ ParamUpdater = (*ClipByValue)(nil)
+	_ ParamUpdater = (*ClipByNorm)(nil)
+)
+
+// Prefix prepends prefix and a dot to the name of each parameter, which is
+// how networks tell apart the parameters of their layers.
+func Prefix(prefix string, ps []Param) []Param {
+	ret := make([]Param, len(ps))
+	for i, p := range ps {
+		ret[i] = Param{Name: prefix + "." + p.Name, Value: p.Value, Grad: p.Grad}
+	}
+	return ret
+}
+
+func (e *elementwise) UpdateParams(ps []Param) {
+	if e.named == nil {
+		e.named = map[string]*slot{}
+	}
+	for _, p := range ps {
+		s, ok := e.named[p.Name]
+		if !ok {
+			s = &slot{}
+			e.named[p.Name] = s
+		}
+		e.updateArray(s, p.Value, p.Grad)
+	}
+}
+
+func (o *WeightDecay) UpdateParams(ps []Param) {
+	ret := make([]Param, len(ps))
+	for i, p := range ps {
+		g := p.Value.Map(func(x float64) float64 {
+			return o.Decay * x
+		}).AddEach(p.Grad)
+		ret[i] = Param{Name: p.Name, Value: p.Value, Grad: g}
+	}
+	updateParams(o.Optimizer, ret)
+}
+func (o *DecoupledWeightDecay) UpdateParams(ps []Param) {
+	updateParams(o.Optimizer, ps)
+	keep := o.keep()
+	for _, p := range ps {
+		p.Value.Scale(keep)
+	}
+}
+func (o *ClipByValue) UpdateParams(ps []Param) {
+	ret := make([]Param, len(ps))
+	for i, p := range ps {
+		ret[i] = Param{Name: p.Name, Value: p.Value, Grad: p.Grad.Map(o.clip)}
+	}
+	updateParams(o.Optimizer, ret)
+}
+func (o *ClipByNorm) UpdateParams(ps []Param) {
+	ClipByGlobalNorm(ps, o.MaxNorm)
+	updateParams(o.Optimizer, ps)
+}
+
+// updateParams updates ps with o at once if o is a ParamUpdater, or else
+// calls UpdateWeightArray for each parameter, in which case o should keep
+// no state or keep it per array.
+func updateParams(o Optimizer, ps []Param) {
+	if u, ok := o.(ParamUpdater); ok {
+		u.UpdateParams(ps)
+		return
+	}
+	for _, p := range ps {
+		o.UpdateWeightArray(p.Value, p.Grad)
+	}
+}
+
+// GlobalNorm is the L2 norm of all the gradients together.
+func GlobalNorm(ps []Param) float64 {
+	ss := 0.0
+	for _, p := range ps {
+		for i := p.Grad.Iterator(); i.OK(); i.Next() {
+			x := p.Grad.Get(i.Index()...)
+			ss += x * x
+		}
+	}
+	return math.Sqrt(ss)
+}
+
+// ClipByGlobalNorm scales all the gradients in place so that their global
+// norm does not exceed maxNorm, and returns the norm before clipping.
+func ClipByGlobalNorm(ps []Param, maxNorm float64) float64 {
+	norm := GlobalNorm(ps)
+	if scale := clipScale(norm, maxNorm); scale != 1 {
+		for _, p := range ps {
+			p.Grad.Scale(scale)
+		}
+	}
+	return norm
+}
+
+// NetworkOptimizer updates every parameter of a network in one step with a
+// single optimizer, which lets a ClipByNorm clip them by their global norm.
+type NetworkOptimizer struct {
+	Optimizer Optimizer
+}
+
+var _ LearningRater = (*NetworkOptimizer)(nil)
+
+func NewNetworkOptimizer(f OptimizerFactory) *NetworkOptimizer {
+	return &NetworkOptimizer{Optimizer: f()}
+}
+
+func (o *NetworkOptimizer) Step(ps []Param) {
+	updateParams(o.Optimizer, ps)
+}
+
+func (o *NetworkOptimizer) LearningRate() float64 {
+	return learningRate(o.Optimizer)
+}
+func (o *NetworkOptimizer) SetLearningRate(lr float64) {
+	setLearningRate(o.Optimizer, lr)
+}
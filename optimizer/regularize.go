@@ -0,0 +1,124 @@
+package optimizer
+
+import (
+	"math"
+
+	mat "github.com/gonum/matrix/mat64"
+
+	"github.com/ajiyoshi/gocnn/nd"
+)
+
+var (
+	_ Optimizer = (*WeightDecay)(nil)
+	_ Optimizer = (*DecoupledWeightDecay)(nil)
+	_ Optimizer = (*ClipByValue)(nil)
+)
+
+// WeightDecay adds the L2 penalty Decay*param to the gradient before passing
+// it to the wrapped optimizer.
+type WeightDecay struct {
+	Optimizer
+	Decay float64
+}
+
+func NewWeightDecay(f OptimizerFactory, decay float64) OptimizerFactory {
+	return func() Optimizer {
+		return &WeightDecay{Optimizer: f(), Decay: decay}
+	}
+}
+
+func (o *WeightDecay) UpdateWeight(param, grad *mat.Dense) {
+	var g mat.Dense
+	g.Scale(o.Decay, param)
+	g.Add(&g, grad)
+	o.Optimizer.UpdateWeight(param, &g)
+}
+func (o *WeightDecay) UpdateWeightArray(param, grad nd.Array) {
+	g := param.Map(func(x float64) float64 {
+		return o.Decay * x
+	}).AddEach(grad)
+	o.Optimizer.UpdateWeightArray(param, g)
+}
+func (o *WeightDecay) UpdateBias(param, grad *mat.Vector) {
+	g := mat.NewVector(param.Len(), nil)
+	g.AddScaledVec(grad, o.Decay, param)
+	o.Optimizer.UpdateBias(param, g)
+}
+
+// DecoupledWeightDecay shrinks the parameter by Lr*Decay*param after the
+// wrapped optimizer has applied the raw gradient, turning any optimizer into
+// its "W" variant as AdamW does for Adam (Loshchilov & Hutter). Lr is the
+// current learning rate of the wrapped optimizer, so that the decay follows
+// its schedule, or 1 if it is not a LearningRater.
+type DecoupledWeightDecay struct {
+	Optimizer
+	Decay float64
+}
+
+func NewDecoupledWeightDecay(f OptimizerFactory, decay float64) OptimizerFactory {
+	return func() Optimizer {
+		return &DecoupledWeightDecay{Optimizer: f(), Decay: decay}
+	}
+}
+
+// NewAdamW is Adam with decoupled weight decay.
+func NewAdamW(lr, beta1, beta2, decay float64) OptimizerFactory {
+	return NewDecoupledWeightDecay(NewAdam(lr, beta1, beta2), decay)
+}
+
+// keep is the factor the parameters are multiplied by after an update.
+func (o *DecoupledWeightDecay) keep() float64 {
+	lr := 1.0
+	if l, ok := o.Optimizer.(LearningRater); ok {
+		lr = l.LearningRate()
+	}
+	return 1 - lr*o.Decay
+}
+
+func (o *DecoupledWeightDecay) UpdateWeight(param, grad *mat.Dense) {
+	o.Optimizer.UpdateWeight(param, grad)
+	param.Scale(o.keep(), param)
+}
+func (o *DecoupledWeightDecay) UpdateWeightArray(param, grad nd.Array) {
+	o.Optimizer.UpdateWeightArray(param, grad)
+	param.Scale(o.keep())
+}
+func (o *DecoupledWeightDecay) UpdateBias(param, grad *mat.Vector) {
+	o.Optimizer.UpdateBias(param, grad)
+	param.ScaleVec(o.keep(), param)
+}
+
+// ClipByValue clamps each element of the gradient into [-Max, Max].
+type ClipByValue struct {
+	Optimizer
+	Max float64
+}
+
+func NewClipByValue(f OptimizerFactory, max float64) OptimizerFactory {
+	return func() Optimizer {
+		return &ClipByValue{Optimizer: f(), Max: max}
+	}
+}
+
+func (o *ClipByValue) clip(x float64) float64 {
+	return math.Max(-o.Max, math.Min(o.Max, x))
+}
+
+func (o *ClipByValue) UpdateWeight(param, grad *mat.Dense) {
+	var g mat.Dense
+	g.Apply(func(i, j int, x float64) float64 {
+		return o.clip(x)
+	}, grad)
+	o.Optimizer.UpdateWeight(param, &g)
+}
+func (o *ClipByValue) UpdateWeightArray(param, grad nd.Array) {
+	o.Optimizer.UpdateWeightArray(param, grad.Map(o.clip))
+}
+func (o *ClipByValue) UpdateBias(param, grad *mat.Vector) {
+	g := mat.NewVector(grad.Len(), nil)
+	g.CloneVec(grad)
+	for i := 0; i < g.Len(); i++ {
+		g.SetVec(i, o.clip(g.At(i, 0)))
+	}
+	o.Optimizer.UpdateBias(param, g)
+}
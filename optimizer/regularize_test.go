@@ -0,0 +1,183 @@
+package optimizer
+
+import (
+	"testing"
+
+	"github.com/gonum/matrix/mat64"
+
+	"github.com/ajiyoshi/gocnn/nd"
+)
+
+// recorder is an Optimizer that only remembers the last gradients it got.
+type recorder struct {
+	w *mat64.Dense
+	a nd.Array
+	b *mat64.Vector
+}
+
+func (o *recorder) UpdateWeight(param, grad *mat64.Dense)  { o.w = grad }
+func (o *recorder) UpdateWeightArray(param, grad nd.Array) { o.a = grad }
+func (o *recorder) UpdateBias(param, grad *mat64.Vector)   { o.b = grad }
+
+func TestWeightDecay(t *testing.T) {
+	r := &recorder{}
+	f := NewWeightDecay(func() Optimizer { return r }, 0.5)
+	o := f()
+
+	param := mat64.NewDense(1, 3, []float64{2, 4, 6})
+	grad := mat64.NewDense(1, 3, []float64{1, 1, 1})
+	o.UpdateWeight(param, grad)
+	expect := mat64.NewDense(1, 3, []float64{2, 3, 4})
+	if !mat64.EqualApprox(r.w, expect, 0.001) {
+		t.Fatalf("expect %v but got %v", expect, r.w)
+	}
+
+	p := nd.NewArray(nd.NewShape(3), []float64{2, 4, 6})
+	g := nd.NewArray(nd.NewShape(3), []float64{1, 1, 1})
+	o.UpdateWeightArray(p, g)
+	ea := nd.NewArray(nd.NewShape(3), []float64{2, 3, 4})
+	if !r.a.Equals(ea) {
+		t.Fatalf("expect %v but got %v", ea, r.a)
+	}
+
+	b := mat64.NewVector(2, []float64{2, -2})
+	o.UpdateBias(b, mat64.NewVector(2, []float64{0, 0}))
+	eb := mat64.NewVector(2, []float64{1, -1})
+	if !mat64.EqualApprox(r.b, eb, 0.001) {
+		t.Fatalf("expect %v but got %v", eb, r.b)
+	}
+}
+
+func TestDecoupledWeightDecay(t *testing.T) {
+	o := NewDecoupledWeightDecay(NewMomentumFactory(1, 0), 0.1)()
+
+	param := mat64.NewDense(1, 2, []float64{10, 20})
+	grad := mat64.NewDense(1, 2, []float64{1, 2})
+	o.UpdateWeight(param, grad)
+	// (param - lr*grad) * (1 - decay)
+	expect := mat64.NewDense(1, 2, []float64{9 * 0.9, 18 * 0.9})
+	if !mat64.EqualApprox(param, expect, 0.001) {
+		t.Fatalf("expect %v but got %v", expect, param)
+	}
+	// the decay follows the scheduled rate
+	o.(LearningRater).SetLearningRate(0.5)
+	param = mat64.NewDense(1, 2, []float64{10, 20})
+	o.UpdateWeight(param, grad)
+	expect = mat64.NewDense(1, 2, []float64{9.5 * 0.95, 19 * 0.95})
+	if !mat64.EqualApprox(param, expect, 0.001) {
+		t.Fatalf("expect %v but got %v", expect, param)
+	}
+
+	// decay as is without a learning rate
+	r := NewDecoupledWeightDecay(func() Optimizer { return &recorder{} }, 0.1)()
+	param = mat64.NewDense(1, 2, []float64{10, 20})
+	r.UpdateWeight(param, grad)
+	expect = mat64.NewDense(1, 2, []float64{9, 18})
+	if !mat64.EqualApprox(param, expect, 0.001) {
+		t.Fatalf("expect %v but got %v", expect, param)
+	}
+}
+
+func TestClipByValue(t *testing.T) {
+	r := &recorder{}
+	o := NewClipByValue(func() Optimizer { return r }, 1)()
+
+	param := mat64.NewDense(1, 3, nil)
+	grad := mat64.NewDense(1, 3, []float64{-3, 0.5, 3})
+	o.UpdateWeight(param, grad)
+	expect := mat64.NewDense(1, 3, []float64{-1, 0.5, 1})
+	if !mat64.EqualApprox(r.w, expect, 0.001) {
+		t.Fatalf("expect %v but got %v", expect, r.w)
+	}
+	if grad.At(0, 0) != -3 {
+		t.Fatalf("grad should not be modified but got %v", grad)
+	}
+}
+
+func TestUpdateParamsFallback(t *testing.T) {
+	r := &recorder{}
+	o := NewClipByValue(func() Optimizer { return r }, 1)().(ParamUpdater)
+	ps := []Param{
+		{Name: "w", Value: nd.Zeros(nd.NewShape(2)), Grad: nd.NewArray(nd.NewShape(2), []float64{-3, 0.5})},
+		{Name: "b", Value: nd.Zeros(nd.NewShape(1)), Grad: nd.NewArray(nd.NewShape(1), []float64{2})},
+	}
+	o.UpdateParams(ps)
+	// the recorder got each parameter, the last one being b
+	expect := nd.NewArray(nd.NewShape(1), []float64{1})
+	if !r.a.Equals(expect) {
+		t.Fatalf("expect %v but got %v", expect, r.a)
+	}
+}
+
+func TestClipByNorm(t *testing.T) {
+	cases := []struct {
+		title string
+		max   float64
+		w     []float64
+		b     []float64
+	}{
+		{title: "norm 5 clipped to 1", max: 1, w: []float64{0.6, 0}, b: []float64{0.8}},
+		{title: "norm 5 not clipped", max: 10, w: []float64{3, 0}, b: []float64{4}},
+	}
+	for _, c := range cases {
+		r := &recorder{}
+		o := NewClipByNorm(func() Optimizer { return r }, c.max)().(ParamUpdater)
+		ps := []Param{
+			{Name: "w", Value: nd.Zeros(nd.NewShape(2)), Grad: nd.NewArray(nd.NewShape(2), []float64{3, 0})},
+			{Name: "b", Value: nd.Zeros(nd.NewShape(1)), Grad: nd.NewArray(nd.NewShape(1), []float64{4})},
+		}
+		o.UpdateParams(ps)
+		w := nd.NewArray(nd.NewShape(2), c.w)
+		b := nd.NewArray(nd.NewShape(1), c.b)
+		if !ps[0].Grad.EqualApprox(w, 0.001) || !ps[1].Grad.EqualApprox(b, 0.001) {
+			t.Fatalf("%s expect %v, %v but got %v, %v", c.title, w, b, ps[0].Grad, ps[1].Grad)
+		}
+	}
+
+	// updated one by one, each gradient is clipped by its own norm
+	r := &recorder{}
+	o := NewClipByNorm(func() Optimizer { return r }, 1)()
+	grad := mat64.NewDense(1, 2, []float64{3, 4})
+	o.UpdateWeight(mat64.NewDense(1, 2, nil), grad)
+	expect := mat64.NewDense(1, 2, []float64{0.6, 0.8})
+	if !mat64.EqualApprox(r.w, expect, 0.001) {
+		t.Fatalf("expect %v but got %v", expect, r.w)
+	}
+	if grad.At(0, 0) != 3 {
+		t.Fatalf("grad should not be modified but got %v", grad)
+	}
+	o.UpdateBias(mat64.NewVector(2, nil), mat64.NewVector(2, []float64{0, 0.5}))
+	if b := r.b.RawVector().Data; !nearlyEqual(b[1], 0.5) {
+		t.Fatalf("expect [0 0.5] but got %v", b)
+	}
+	o.UpdateWeightArray(nd.Zeros(nd.NewShape(1)), nd.NewArray(nd.NewShape(1), []float64{-2}))
+	if a := nd.NewArray(nd.NewShape(1), []float64{-1}); !r.a.EqualApprox(a, 0.001) {
+		t.Fatalf("expect %v but got %v", a, r.a)
+	}
+}
+
+func nearlyEqual(a, b float64) bool {
+	d := a - b
+	return -0.001 < d && d < 0.001
+}
+
+func TestClipByGlobalNorm(t *testing.T) {
+	ps := []Param{
+		{Name: "w", Value: nd.Zeros(nd.NewShape(2)), Grad: nd.NewArray(nd.NewShape(2), []float64{3, 0})},
+		{Name: "b", Value: nd.Zeros(nd.NewShape(1)), Grad: nd.NewArray(nd.NewShape(1), []float64{4})},
+	}
+	norm := ClipByGlobalNorm(ps, 1)
+	if !nearlyEqual(norm, 5) {
+		t.Fatalf("expect 5 but got %v", norm)
+	}
+	if !nearlyEqual(GlobalNorm(ps), 1) {
+		t.Fatalf("expect 1 but got %v", GlobalNorm(ps))
+	}
+
+	o := NewNetworkOptimizer(NewSGDFactory(1))
+	o.Step(ps)
+	expect := nd.NewArray(nd.NewShape(2), []float64{-0.6, 0})
+	if !ps[0].Value.Equals(expect) {
+		t.Fatalf("expect %v but got %v", expect, ps[0].Value)
+	}
+}
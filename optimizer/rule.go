@@ -0,0 +1,116 @@
+package optimizer
+
+import (
+	mat "github.com/gonum/matrix/mat64"
+
+	"github.com/ajiyoshi/gocnn/nd"
+)
+
+// updater is an element-wise update rule. It gets flat views of a parameter
+// and its gradient together with the state kept for that parameter, and
+// updates param in place.
+type updater interface {
+	update(s *slot, param, grad []float64)
+}
+
+// slot is the state an optimizer keeps for one parameter.
+type slot struct {
+	iter float64
+	m    []float64
+	v    []float64
+}
+
+func (s *slot) init(n int) {
+	if s.m == nil {
+		s.m = make([]float64, n)
+		s.v = make([]float64, n)
+	}
+}
+
+// elementwise implements Optimizer on top of an updater, so that every
+// optimizer writes its rule once for *mat.Dense, *mat.Vector and nd.Array.
+type elementwise struct {
+	rule   updater
+	weight slot
+	bias   slot
+	array  slot
+	named  map[string]*slot
+}
+
+func (e *elementwise) UpdateWeight(param, grad *mat.Dense) {
+	p, store := flatDense(param)
+	g, _ := flatDense(grad)
+	e.rule.update(&e.weight, p, g)
+	store()
+}
+func (e *elementwise) UpdateWeightArray(param, grad nd.Array) {
+	e.updateArray(&e.array, param, grad)
+}
+func (e *elementwise) UpdateBias(param, grad *mat.Vector) {
+	p, store := flatVector(param)
+	g, _ := flatVector(grad)
+	e.rule.update(&e.bias, p, g)
+	store()
+}
+func (e *elementwise) updateArray(s *slot, param, grad nd.Array) {
+	if !param.Shape().Equals(grad.Shape()) {
+		panic("shape should be same")
+	}
+	p, store := flatArray(param)
+	g, _ := flatArray(grad)
+	e.rule.update(s, p, g)
+	store()
+}
+
+// flatDense returns the elements of m in row-major order and a function
+// writing them back. Contiguous storage is shared instead of copied.
+func flatDense(m *mat.Dense) ([]float64, func()) {
+	r, c := m.Dims()
+	raw := m.RawMatrix()
+	if raw.Stride == c {
+		return raw.Data[:r*c], func() {}
+	}
+	buf := make([]float64, 0, r*c)
+	for i := 0; i < r; i++ {
+		buf = append(buf, m.RawRowView(i)...)
+	}
+	return buf, func() {
+		for i := 0; i < r; i++ {
+			m.SetRow(i, buf[i*c:(i+1)*c])
+		}
+	}
+}
+
+func flatVector(v *mat.Vector) ([]float64, func()) {
+	n := v.Len()
+	raw := v.RawVector()
+	if raw.Inc == 1 {
+		return raw.Data[:n], func() {}
+	}
+	buf := make([]float64, n)
+	for i := range buf {
+		buf[i] = v.At(i, 0)
+	}
+	return buf, func() {
+		for i, x := range buf {
+			v.SetVec(i, x)
+		}
+	}
+}
+
+func flatArray(a nd.Array) ([]float64, func()) {
+	if data, ok := nd.Contiguous(a); ok {
+		return data, func() {}
+	}
+	buf := make([]float64, 0, a.Shape().Size())
+	for i := a.Iterator(); i.OK(); i.Next() {
+		buf = append(buf, a.Get(i.Index()...))
+	}
+	return buf, func() {
+		k := 0
+		for i := a.Iterator(); i.OK(); i.Next() {
+			a.Set(buf[k], i.Index()...)
+			k++
+		}
+	}
+}
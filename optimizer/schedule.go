@@ -0,0 +1,263 @@
+package optimizer
+
+import (
+	"fmt"
+	"math"
+)
+
+// LearningRater is an optimizer whose learning rate can be changed while
+// training.
+type LearningRater interface {
+	LearningRate() float64
+	SetLearningRate(float64)
+}
+
+var (
+	_ LearningRater = (*SGD)(nil)
+	_ LearningRater = (*Momentum)(nil)
+	_ LearningRater = (*Nesterov)(nil)
+	_ LearningRater = (*AdaGrad)(nil)
+	_ LearningRater = (*RMSProp)(nil)
+	_ LearningRater = (*AdaDelta)(nil)
+	_ LearningRater = (*Adam)(nil)
+	_ LearningRater = (*WeightDecay)(nil)
+	_ LearningRater = (*DecoupledWeightDecay)(nil)
+	_ LearningRater = (*ClipByValue)(nil)
+	_ LearningRater = (*ClipByNorm)(nil)
+)
+
+func (o *SGD) LearningRate() float64           { return o.Lr }
+func (o *SGD) SetLearningRate(lr float64)      { o.Lr = lr }
+func (o *Momentum) LearningRate() float64      { return o.Lr }
+func (o *Momentum) SetLearningRate(lr float64) { o.Lr = lr }
+func (o *Nesterov) LearningRate() float64      { return o.Lr }
+func (o *Nesterov) SetLearningRate(lr float64) { o.Lr = lr }
+func (o *AdaGrad) LearningRate() float64       { return o.Lr }
+func (o *AdaGrad) SetLearningRate(lr float64)  { o.Lr = lr }
+func (o *RMSProp) LearningRate() float64       { return o.Lr }
+func (o *RMSProp) SetLearningRate(lr float64)  { o.Lr = lr }
+func (o *AdaDelta) LearningRate() float64      { return o.Lr }
+func (o *AdaDelta) SetLearningRate(lr float64) { o.Lr = lr }
+func (o *Adam) LearningRate() float64          { return o.Lr }
+func (o *Adam) SetLearningRate(lr float64)     { o.Lr = lr }
+
+func (o *WeightDecay) LearningRate() float64 {
+	return learningRate(o.Optimizer)
+}
+func (o *WeightDecay) SetLearningRate(lr float64) {
+	setLearningRate(o.Optimizer, lr)
+}
+func (o *DecoupledWeightDecay) LearningRate() float64 {
+	return learningRate(o.Optimizer)
+}
+func (o *DecoupledWeightDecay) SetLearningRate(lr float64) {
+	setLearningRate(o.Optimizer, lr)
+}
+func (o *ClipByValue) LearningRate() float64 {
+	return learningRate(o.Optimizer)
+}
+func (o *ClipByValue) SetLearningRate(lr float64) {
+	setLearningRate(o.Optimizer, lr)
+}
+func (o *ClipByNorm) LearningRate() float64 {
+	return learningRate(o.Optimizer)
+}
+func (o *ClipByNorm) SetLearningRate(lr float64) {
+	setLearningRate(o.Optimizer, lr)
+}
+
+func learningRate(o Optimizer) float64 {
+	if l, ok := o.(LearningRater); ok {
+		return l.LearningRate()
+	}
+	return 0
+}
+func setLearningRate(o Optimizer, lr float64) {
+	if l, ok := o.(LearningRater); ok {
+		l.SetLearningRate(lr)
+	}
+}
+
+// group is the set of optimizers created through Factory, whose learning
+// rate is changed together. The base rate is taken from the first one.
+type group struct {
+	opts []Optimizer
+	base float64
+	lr   float64
+}
+
+func (g *group) add(o Optimizer) {
+	if len(g.opts) == 0 {
+		g.base = learningRate(o)
+		g.lr = g.base
+	}
+	g.opts = append(g.opts, o)
+	setLearningRate(o, g.lr)
+}
+
+// LearningRate is the rate currently set to every optimizer of the group.
+func (g *group) LearningRate() float64 {
+	return g.lr
+}
+
+func (g *group) SetLearningRate(lr float64) {
+	g.lr = lr
+	for _, o := range g.opts {
+		setLearningRate(o, lr)
+	}
+}
+
+// Schedule gives the learning rate of an epoch (counted from 0) from the
+// initial learning rate base.
+type Schedule interface {
+	Rate(epoch int, base float64) float64
+}
+
+// Scheduler sets the learning rate given by Schedule to every optimizer
+// created by Factory, once per epoch.
+type Scheduler struct {
+	Schedule Schedule
+	group
+	epoch int
+}
+
+func NewScheduler(s Schedule) *Scheduler {
+	return &Scheduler{Schedule: s}
+}
+
+func (s *Scheduler) Factory(f OptimizerFactory) OptimizerFactory {
+	return func() Optimizer {
+		o := f()
+		first := len(s.opts) == 0
+		s.group.add(o)
+		if first {
+			s.SetLearningRate(s.Schedule.Rate(s.epoch, s.base))
+		}
+		return o
+	}
+}
+
+// Step moves to the next epoch.
+func (s *Scheduler) Step() {
+	s.epoch++
+	s.SetLearningRate(s.Schedule.Rate(s.epoch, s.base))
+}
+
+func (s *Scheduler) Epoch() int {
+	return s.epoch
+}
+
+// StepDecay multiplies the rate by Gamma every Step epochs. Rate panics if
+// Step is not positive.
+type StepDecay struct {
+	Step  int
+	Gamma float64
+}
+
+func (s *StepDecay) Rate(epoch int, base float64) float64 {
+	if s.Step <= 0 {
+		panic(fmt.Sprintf("StepDecay.Step should be positive but got %d", s.Step))
+	}
+	return base * math.Pow(s.Gamma, float64(epoch/s.Step))
+}
+
+// ExponentialDecay multiplies the rate by Gamma every epoch.
+type ExponentialDecay struct {
+	Gamma float64
+}
+
+func (s *ExponentialDecay) Rate(epoch int, base float64) float64 {
+	return base * math.Pow(s.Gamma, float64(epoch))
+}
+
+// CosineAnnealing anneals the rate from base to Min along a half cosine over
+// Period epochs and then restarts, multiplying the period by Mult each time
+// (SGDR). Mult < 2 keeps the period fixed. Rate panics if Period is not
+// positive.
+type CosineAnnealing struct {
+	Period int
+	Mult   int
+	Min    float64
+}
+
+func (s *CosineAnnealing) Rate(epoch int, base float64) float64 {
+	if s.Period <= 0 {
+		panic(fmt.Sprintf("CosineAnnealing.Period should be positive but got %d", s.Period))
+	}
+	t, period := epoch, s.Period
+	for t >= period {
+		t -= period
+		if s.Mult > 1 {
+			period *= s.Mult
+		}
+	}
+	cos := math.Cos(math.Pi * float64(t) / float64(period))
+	return s.Min + (base-s.Min)*(1+cos)/2
+}
+
+// LinearWarmup raises the rate linearly up to base during the first Epochs
+// epochs and then follows After, started from its own epoch 0.
+// A nil After keeps the rate at base.
+type LinearWarmup struct {
+	Epochs int
+	After  Schedule
+}
+
+func (s *LinearWarmup) Rate(epoch int, base float64) float64 {
+	if epoch < s.Epochs {
+		return base * float64(epoch+1) / float64(s.Epochs)
+	}
+	if s.After == nil {
+		return base
+	}
+	return s.After.Rate(epoch-s.Epochs, base)
+}
+
+// ReduceOnPlateau multiplies the rate of every optimizer created by Factory
+// by Factor when the monitored metric has not improved by more than MinDelta
+// for Patience steps in a row. Set Maximize for metrics such as accuracy.
+type ReduceOnPlateau struct {
+	Factor   float64
+	Patience int
+	MinDelta float64
+	MinLr    float64
+	Maximize bool
+	group
+
+	best float64
+	wait int
+	seen bool
+}
+
+func NewReduceOnPlateau(factor float64, patience int) *ReduceOnPlateau {
+	return &ReduceOnPlateau{
+		Factor:   factor,
+		Patience: patience,
+	}
+}
+
+func (s *ReduceOnPlateau) Factory(f OptimizerFactory) OptimizerFactory {
+	return func() Optimizer {
+		o := f()
+		s.group.add(o)
+		return o
+	}
+}
+
+// Step reports the metric of the epoch just finished, e.g. validation loss.
+func (s *ReduceOnPlateau) Step(metric float64) {
+	if !s.Maximize {
+		metric = -metric
+	}
+	if !s.seen || metric > s.best+s.MinDelta {
+		s.best = metric
+		s.seen = true
+		s.wait = 0
+		return
+	}
+	s.wait++
+	if s.wait > s.Patience {
+		s.SetLearningRate(math.Max(s.lr*s.Factor, s.MinLr))
+		s.wait = 0
+	}
+}
@@ -0,0 +1,98 @@
+package optimizer
+
+import (
+	"testing"
+)
+
+func TestSchedule(t *testing.T) {
+	cases := []struct {
+		title  string
+		s      Schedule
+		expect []float64
+	}{
+		{
+			title:  "StepDecay",
+			s:      &StepDecay{Step: 2, Gamma: 0.5},
+			expect: []float64{1, 1, 0.5, 0.5, 0.25},
+		},
+		{
+			title:  "ExponentialDecay",
+			s:      &ExponentialDecay{Gamma: 0.5},
+			expect: []float64{1, 0.5, 0.25, 0.125},
+		},
+		{
+			title:  "CosineAnnealing",
+			s:      &CosineAnnealing{Period: 2},
+			expect: []float64{1, 0.5, 1, 0.5},
+		},
+		{
+			title:  "CosineAnnealing with Mult",
+			s:      &CosineAnnealing{Period: 1, Mult: 2, Min: 0.2},
+			expect: []float64{1, 1, 0.6, 1, 0.2 + 0.8*0.8535533, 0.6},
+		},
+		{
+			title:  "LinearWarmup",
+			s:      &LinearWarmup{Epochs: 4, After: &ExponentialDecay{Gamma: 0.5}},
+			expect: []float64{0.25, 0.5, 0.75, 1, 1, 0.5},
+		},
+	}
+	for _, c := range cases {
+		for epoch, expect := range c.expect {
+			actual := c.s.Rate(epoch, 1)
+			if !nearlyEqual(expect, actual) {
+				t.Fatalf("%s epoch %d expect %v but got %v", c.title, epoch, expect, actual)
+			}
+		}
+	}
+}
+
+func TestScheduleZeroPeriod(t *testing.T) {
+	for _, s := range []Schedule{&StepDecay{Gamma: 0.5}, &CosineAnnealing{}} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Fatalf("%T without a period should panic", s)
+				}
+			}()
+			s.Rate(1, 1)
+		}()
+	}
+}
+
+func TestScheduler(t *testing.T) {
+	s := NewScheduler(&LinearWarmup{Epochs: 2})
+	f := s.Factory(NewWeightDecay(NewMomentumFactory(0.1, 0.9), 0.01))
+	o1, o2 := f(), f().(*WeightDecay)
+
+	if !nearlyEqual(learningRate(o1), 0.05) || !nearlyEqual(o2.Optimizer.(*Momentum).Lr, 0.05) {
+		t.Fatalf("expect 0.05 but got %v, %v", learningRate(o1), learningRate(o2))
+	}
+	s.Step()
+	if !nearlyEqual(learningRate(o1), 0.1) || !nearlyEqual(learningRate(o2), 0.1) {
+		t.Fatalf("expect 0.1 but got %v, %v", learningRate(o1), learningRate(o2))
+	}
+}
+
+func TestReduceOnPlateau(t *testing.T) {
+	s := NewReduceOnPlateau(0.5, 1)
+	s.MinLr = 0.3
+	o := s.Factory(NewSGDFactory(1))()
+
+	for i, c := range []struct {
+		metric float64
+		expect float64
+	}{
+		{metric: 1.0, expect: 1},
+		{metric: 0.9, expect: 1},
+		{metric: 0.95, expect: 1},
+		{metric: 0.9, expect: 0.5},
+		{metric: 0.8, expect: 0.5},
+		{metric: 0.8, expect: 0.5},
+		{metric: 0.8, expect: 0.3},
+	} {
+		s.Step(c.metric)
+		if !nearlyEqual(learningRate(o), c.expect) {
+			t.Fatalf("step %d expect %v but got %v", i, c.expect, learningRate(o))
+		}
+	}
+}
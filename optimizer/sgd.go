@@ -0,0 +1,58 @@
+package optimizer
+
+var (
+	_ Optimizer = (*SGD)(nil)
+	_ Optimizer = (*Nesterov)(nil)
+)
+
+type SGD struct {
+	Lr float64
+	elementwise
+}
+
+func NewSGDFactory(lr float64) OptimizerFactory {
+	return func() Optimizer {
+		return NewSGD(lr)
+	}
+}
+
+func NewSGD(lr float64) *SGD {
+	o := &SGD{Lr: lr}
+	o.rule = o
+	return o
+}
+
+func (o *SGD) update(s *slot, param, grad []float64) {
+	for i, g := range grad {
+		param[i] -= o.Lr * g
+	}
+}
+
+// Nesterov is momentum SGD with Nesterov's accelerated gradient.
+type Nesterov struct {
+	Lr       float64
+	Momentum float64
+	elementwise
+}
+
+func NewNesterovFactory(lr, mo float64) OptimizerFactory {
+	return func() Optimizer {
+		return NewNesterov(lr, mo)
+	}
+}
+
+func NewNesterov(lr, mo float64) *Nesterov {
+	o := &Nesterov{Lr: lr, Momentum: mo}
+	o.rule = o
+	return o
+}
+
+func (o *Nesterov) update(s *slot, param, grad []float64) {
+	s.init(len(param))
+	mo := o.Momentum
+	for i, g := range grad {
+		prev := s.m[i]
+		s.m[i] = mo*s.m[i] - o.Lr*g
+		param[i] += -mo*prev + (1+mo)*s.m[i]
+	}
+}
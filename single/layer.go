@@ -1,125 +1,74 @@
 package single
 
 import (
-	"fmt"
 	"github.com/gonum/matrix/mat64"
 
-	"github.com/ajiyoshi/gocnn/matrix"
+	"github.com/ajiyoshi/gocnn/batch"
 	"github.com/ajiyoshi/gocnn/optimizer"
 )
 
+// Layer is a batch.Layer fed one sample at a time.
 type Layer interface {
 	Forward(*mat64.Vector) *mat64.Vector
 	Backward(*mat64.Vector) *mat64.Vector
 	Update()
+	Batch() batch.Layer
 }
 
 type LastLayer interface {
 	Forward(x, t *mat64.Vector) float64
 	Backward(float64) *mat64.Vector
+	Batch() batch.LastLayer
 }
 
 var (
-	_ Layer = &AffineLayer{}
-	_ Layer = &ReLULayer{}
+	_ Layer     = &AffineLayer{}
+	_ Layer     = &ReLULayer{}
+	_ LastLayer = &SoftMaxWithLoss{}
 )
 
 type AffineLayer struct {
-	dimIn     int
-	dimOut    int
-	Weight    *mat64.Dense
-	Bias      *mat64.Vector
-	DWeight   *mat64.Dense
-	DBias     *mat64.Vector
-	x         *mat64.Vector
-	optimizer optimizer.Optimizer
+	*batch.AffineLayer
 }
 
 func NewAffineLayer(w *mat64.Dense, b *mat64.Vector, o optimizer.Optimizer) *AffineLayer {
-	r, c := w.Dims()
-	if c != b.Len() {
-		panic(fmt.Sprintf("cols:%d, b.Len():%d", c, b.Len()))
-	}
-	return &AffineLayer{
-		dimIn:     r,
-		dimOut:    c,
-		Weight:    w,
-		Bias:      b,
-		DWeight:   mat64.NewDense(r, c, nil),
-		DBias:     mat64.NewVector(b.Len(), nil),
-		x:         mat64.NewVector(r, nil),
-		optimizer: o,
-	}
+	return &AffineLayer{batch.NewAffineLayer(w, b, o)}
 }
 
 func (l *AffineLayer) Forward(x *mat64.Vector) *mat64.Vector {
-	if x.Len() != l.dimIn {
-		panic(fmt.Sprintf("expect %d but got %d", l.dimIn, x.Len()))
-	}
-	l.x.CloneVec(x)
-	ret := mat64.NewVector(l.dimOut, nil)
-	ret.MulVec(l.Weight.T(), x)
-	ret.AddVec(ret, l.Bias)
-	return ret
+	return batch.ToVector(l.AffineLayer.Forward(batch.FromVector(x)))
 }
-
 func (l *AffineLayer) Backward(dout *mat64.Vector) *mat64.Vector {
-	if dout.Len() != l.dimOut {
-		panic(fmt.Sprintf("expect %d but got %d", l.dimOut, dout.Len()))
-	}
-	dx := mat64.NewVector(l.dimIn, nil)
-	dx.MulVec(l.Weight, dout)
-
-	l.DWeight.Mul(l.x, dout.T())
-	l.DBias.CloneVec(dout)
-
-	return dx
+	return batch.ToVector(l.AffineLayer.Backward(batch.FromVector(dout)))
 }
-
-func (l *AffineLayer) Update() {
-	l.optimizer.UpdateWeight(l.Weight, l.DWeight)
-	l.optimizer.UpdateBias(l.Bias, l.DBias)
+func (l *AffineLayer) Batch() batch.Layer {
+	return l.AffineLayer
 }
 
 type ReLULayer struct {
-	mask *mat64.Vector
+	batch.ReLULayer
 }
 
 func (l *ReLULayer) Forward(x *mat64.Vector) *mat64.Vector {
-	l.mask = matrix.VecClone(x)
-	matrix.VecApply(l.mask, func(val float64) float64 {
-		if val < 0 {
-			return 0
-		} else {
-			return 1
-		}
-	})
-	ret := mat64.NewVector(x.Len(), nil)
-	ret.MulElemVec(x, l.mask)
-	return ret
+	return batch.ToVector(l.ReLULayer.Forward(batch.FromVector(x)))
 }
 func (l *ReLULayer) Backward(dout *mat64.Vector) *mat64.Vector {
-	dout.MulElemVec(dout, l.mask)
-	return dout
+	return batch.ToVector(l.ReLULayer.Backward(batch.FromVector(dout)))
 }
-func (l *ReLULayer) Update() {
+func (l *ReLULayer) Batch() batch.Layer {
+	return &l.ReLULayer
 }
 
 type SoftMaxWithLoss struct {
-	loss float64
-	y    *mat64.Vector
-	t    *mat64.Vector
+	batch.SoftMaxWithLoss
 }
 
 func (l *SoftMaxWithLoss) Forward(x, t *mat64.Vector) float64 {
-	l.t = matrix.VecClone(t)
-	l.y = matrix.SoftMaxV(x)
-	l.loss = matrix.CrossEntropyError(l.y, t)
-	return l.loss
+	return l.SoftMaxWithLoss.Forward(batch.FromVector(x), batch.FromVector(t))
 }
-
 func (l *SoftMaxWithLoss) Backward(dout float64) *mat64.Vector {
-	dx := mat64.NewVector(l.y.Len(), nil)
-	dx.SubVec(l.y, l.t)
-	return dx
+	return batch.ToVector(l.SoftMaxWithLoss.Backward(dout))
+}
+func (l *SoftMaxWithLoss) Batch() batch.LastLayer {
+	return &l.SoftMaxWithLoss
 }
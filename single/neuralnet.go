@@ -2,6 +2,8 @@ package single
 
 import (
 	"github.com/gonum/matrix/mat64"
+
+	"github.com/ajiyoshi/gocnn/batch"
 )
 
 type NeuralNetLayers interface {
@@ -11,44 +13,40 @@ type NeuralNetLayers interface {
 
 var _ NeuralNetLayers = &TwoLayerNN{}
 
+// NeuralNet runs a batch.NeuralNet made of the batch layers of impl on one
+// sample at a time.
 type NeuralNet struct {
 	impl NeuralNetLayers
+	nn   *batch.NeuralNet
 }
 
 func NewNeuralNet(imp NeuralNetLayers) *NeuralNet {
-	return &NeuralNet{imp}
+	return &NeuralNet{
+		impl: imp,
+		nn:   batch.NewNeuralNet(&batchLayers{imp}),
+	}
 }
 
 func (nn *NeuralNet) Layers() []Layer {
 	return nn.impl.Layers()
 }
+func (nn *NeuralNet) Batch() *batch.NeuralNet {
+	return nn.nn
+}
 func (nn *NeuralNet) Predict(x *mat64.Vector) *mat64.Vector {
-	for _, layer := range nn.Layers() {
-		x = layer.Forward(x)
-	}
-	return x
+	return batch.ToVector(nn.nn.Predict(batch.FromVector(x)))
 }
 func (nn *NeuralNet) Loss(x, t *mat64.Vector) float64 {
-	y := nn.Predict(x)
-	return nn.impl.Last().Forward(y, t)
+	return nn.nn.Loss(batch.FromVector(x), batch.FromVector(t))
 }
 func (nn *NeuralNet) BackProp() *mat64.Vector {
-	dout := nn.impl.Last().Backward(1)
-	for _, layer := range LayerReverse(nn.Layers()) {
-		dout = layer.Backward(dout)
-	}
-	return dout
+	return batch.ToVector(nn.nn.BackProp())
 }
 func (nn *NeuralNet) Update() {
-	for _, layer := range nn.Layers() {
-		layer.Update()
-	}
+	nn.nn.Update()
 }
 func (nn *NeuralNet) Train(x, t *mat64.Vector) float64 {
-	loss := nn.Loss(x, t)
-	nn.BackProp()
-	nn.Update()
-	return loss
+	return nn.nn.Train(batch.FromVector(x), batch.FromVector(t))
 }
 
 func LayerReverse(ls []Layer) []Layer {
@@ -59,3 +57,19 @@ func LayerReverse(ls []Layer) []Layer {
 	}
 	return ret
 }
+
+type batchLayers struct {
+	impl NeuralNetLayers
+}
+
+func (b *batchLayers) Layers() []batch.Layer {
+	ls := b.impl.Layers()
+	ret := make([]batch.Layer, len(ls))
+	for i, l := range ls {
+		ret[i] = l.Batch()
+	}
+	return ret
+}
+func (b *batchLayers) Last() batch.LastLayer {
+	return b.impl.Last().Batch()
+}
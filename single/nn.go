@@ -1,9 +1,7 @@
 package single
 
 import (
-	"github.com/gonum/matrix/mat64"
-
-	"github.com/ajiyoshi/gocnn/matrix"
+	"github.com/ajiyoshi/gocnn/batch"
 	"github.com/ajiyoshi/gocnn/optimizer"
 )
 
@@ -38,8 +36,5 @@ const WeightInitStd = 0.1
 const weightInitStd = WeightInitStd
 
 func NewAffine(input, output int, op optimizer.Optimizer) *AffineLayer {
-	w := matrix.RandamDense(input, output)
-	w.Scale(weightInitStd, w)
-	b := mat64.NewVector(output, nil)
-	return NewAffineLayer(w, b, op)
+	return &AffineLayer{batch.NewAffine(weightInitStd, input, output, op)}
 }
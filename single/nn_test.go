@@ -25,7 +25,10 @@ func TestBackPropGrad(t *testing.T) {
 
 	buf := make([]float64, len)
 
-	data, label := m.At(rand.Intn(m.Images.Num))
+	data, label, err := m.At(rand.Intn(m.Images.Num))
+	if err != nil {
+		t.Fatal(err)
+	}
 	mnist.LoadVec(data, buf)
 	x := mat64.NewVector(len, buf)
 	l := mnist.LoadLabel(label)
@@ -0,0 +1,53 @@
+package train
+
+import (
+	"math/rand"
+
+	mat "github.com/gonum/matrix/mat64"
+
+	"github.com/ajiyoshi/gocnn"
+)
+
+// Augmented is a Loader applying Transform to every example of the batches
+// of Loader, seen as images of Ch x Row x Col. The random numbers of an
+// epoch are determined by Seed and the epoch, so that the augmented batches
+// are reproducible as long as Loader is.
+type Augmented struct {
+	Loader    Loader
+	Transform gocnn.Transform
+	Ch        int
+	Row       int
+	Col       int
+	Seed      int64
+
+	rand *rand.Rand
+}
+
+var _ Loader = (*Augmented)(nil)
+
+func NewAugmented(l Loader, t gocnn.Transform, ch, row, col int, seed int64) *Augmented {
+	return &Augmented{Loader: l, Transform: t, Ch: ch, Row: row, Col: col, Seed: seed}
+}
+
+func (a *Augmented) Reset(epoch int) {
+	a.rand = rand.New(rand.NewSource(a.Seed + int64(epoch)))
+	a.Loader.Reset(epoch)
+}
+
+func (a *Augmented) Next() (x, t mat.Matrix, ok bool) {
+	x, t, ok = a.Loader.Next()
+	if !ok {
+		return nil, nil, false
+	}
+	if a.rand == nil {
+		a.rand = rand.New(rand.NewSource(a.Seed))
+	}
+	r, _ := x.Dims()
+	img := gocnn.NewReshaped(gocnn.NewShape(r, a.Ch, a.Row, a.Col), x)
+	gocnn.ApplyAll(a.Transform, img, a.rand)
+	return img.Matrix(), t, true
+}
+
+func (a *Augmented) Err() error {
+	return a.Loader.Err()
+}
@@ -0,0 +1,116 @@
+package train
+
+import (
+	"github.com/ajiyoshi/gocnn/nd"
+	"github.com/ajiyoshi/gocnn/optimizer"
+)
+
+// Snapshot is a copy of the values of parameters.
+type Snapshot []nd.Array
+
+func TakeSnapshot(p optimizer.Parameterized) Snapshot {
+	ps := p.Params()
+	ret := make(Snapshot, len(ps))
+	for i, x := range ps {
+		ret[i] = x.Value.Clone()
+	}
+	return ret
+}
+
+// Restore writes the values of the snapshot back to the parameters of p.
+func (s Snapshot) Restore(p optimizer.Parameterized) {
+	ps := p.Params()
+	if len(ps) != len(s) {
+		panic("number of parameters should be same")
+	}
+	for k, x := range ps {
+		if !x.Value.Shape().Equals(s[k].Shape()) {
+			panic("shape should be same")
+		}
+		for i := x.Value.Iterator(); i.OK(); i.Next() {
+			x.Value.Set(s[k].Get(i.Index()...), i.Index()...)
+		}
+	}
+}
+
+// ValLoss and ValAccracy are metrics to monitor by EarlyStopping. Without
+// a validation set, they fall back to the training loss and accuracy.
+func ValLoss(s *Status) float64 {
+	if !s.Validated {
+		return s.Loss
+	}
+	return s.ValLoss
+}
+func ValAccracy(s *Status) float64 {
+	if !s.Validated {
+		return s.Accracy
+	}
+	return s.ValAccracy
+}
+
+// EarlyStopping stops the training when the metric Monitor has not improved
+// by more than MinDelta for Patience epochs in a row. Set Maximize for
+// metrics such as accuracy. With a Model, it keeps the parameters of the
+// best epoch and restores them at the end of the training. The state of the
+// optimizer, like the momentum, is not kept, so it is that of the last epoch
+// and not of the restored one.
+type EarlyStopping struct {
+	Monitor  func(*Status) float64
+	Maximize bool
+	Patience int
+	MinDelta float64
+	Model    optimizer.Parameterized
+
+	best      float64
+	bestEpoch int
+	wait      int
+	seen      bool
+	snapshot  Snapshot
+}
+
+var _ Callback = (*EarlyStopping)(nil)
+
+// NewEarlyStopping monitors ValLoss and restores the best parameters of m
+// unless m is nil.
+func NewEarlyStopping(m optimizer.Parameterized, patience int) *EarlyStopping {
+	return &EarlyStopping{Monitor: ValLoss, Patience: patience, Model: m}
+}
+
+// Best returns the best value of the metric and its epoch.
+func (e *EarlyStopping) Best() (float64, int) {
+	return e.best, e.bestEpoch
+}
+
+func (e *EarlyStopping) improved(x float64) bool {
+	if !e.seen {
+		return true
+	}
+	if e.Maximize {
+		return x > e.best+e.MinDelta
+	}
+	return x < e.best-e.MinDelta
+}
+
+func (e *EarlyStopping) BatchEnd(s *Status) {}
+
+func (e *EarlyStopping) EpochEnd(s *Status) {
+	x := e.Monitor(s)
+	if e.improved(x) {
+		e.best, e.bestEpoch, e.seen = x, s.Epoch, true
+		e.wait = 0
+		if e.Model != nil {
+			e.snapshot = TakeSnapshot(e.Model)
+		}
+		return
+	}
+	e.wait++
+	if e.wait >= e.Patience {
+		s.Stop = true
+	}
+}
+
+func (e *EarlyStopping) TrainEnd(s *Status) {
+	if e.snapshot != nil {
+		e.snapshot.Restore(e.Model)
+	}
+}
@@ -0,0 +1,99 @@
+package train
+
+import (
+	"testing"
+
+	mat "github.com/gonum/matrix/mat64"
+
+	"github.com/ajiyoshi/gocnn/nd"
+	"github.com/ajiyoshi/gocnn/optimizer"
+)
+
+// scripted counts the batches it is trained on in its only parameter and
+// reports losses[i] after i+1 batches.
+type scripted struct {
+	w      nd.Array
+	losses []float64
+}
+
+func (m *scripted) Train(x, t mat.Matrix) float64 {
+	m.w.Set(m.w.Get(0)+1, 0)
+	return 0
+}
+func (m *scripted) Loss(x, t mat.Matrix) float64 {
+	return m.losses[int(m.w.Get(0))-1]
+}
+func (m *scripted) Accracy(x, t mat.Matrix) float64 {
+	return -m.Loss(x, t)
+}
+func (m *scripted) Params() []optimizer.Param {
+	return []optimizer.Param{{Name: "w", Value: m.w, Grad: m.w}}
+}
+
+func TestEarlyStopping(t *testing.T) {
+	x, tt := toyData()
+	losses := []float64{5, 4, 3, 3.5, 3.2, 4, 1}
+	cases := []struct {
+		title     string
+		patience  int
+		minDelta  float64
+		maximize  bool
+		epochs    int
+		bestEpoch int
+	}{
+		{title: "stop", patience: 3, epochs: 6, bestEpoch: 2},
+		{title: "no stop", patience: 5, epochs: 7, bestEpoch: 6},
+		{title: "min delta", patience: 1, minDelta: 1.5, epochs: 2, bestEpoch: 0},
+		{title: "maximize", patience: 3, maximize: true, epochs: 6, bestEpoch: 2},
+	}
+	for _, c := range cases {
+		m := &scripted{w: nd.Zeros(nd.NewShape(1)), losses: losses}
+		e := NewEarlyStopping(m, c.patience)
+		e.MinDelta = c.minDelta
+		if c.maximize {
+			e.Monitor = ValAccracy
+			e.Maximize = true
+		}
+		history, err := NewTrainer(m, &sliceLoader{x: x, t: tt, size: 6}, len(losses)).
+			Validate(&sliceLoader{x: x, t: tt, size: 6}).
+			Add(e).
+			Run()
+		if err != nil {
+			t.Fatalf("%s %v", c.title, err)
+		}
+		if len(history) != c.epochs {
+			t.Fatalf("%s expect %d epochs but got %d", c.title, c.epochs, len(history))
+		}
+		if _, epoch := e.Best(); epoch != c.bestEpoch {
+			t.Fatalf("%s expect best epoch %d but got %d", c.title, c.bestEpoch, epoch)
+		}
+		if w := m.w.Get(0); w != float64(c.bestEpoch+1) {
+			t.Fatalf("%s expect restored %d but got %v", c.title, c.bestEpoch+1, w)
+		}
+	}
+}
+
+func TestEarlyStoppingWithoutValidation(t *testing.T) {
+	if v := ValLoss(&Status{Loss: 2}); v != 2 {
+		t.Fatalf("ValLoss without validation expect %v but got %v", 2.0, v)
+	}
+	if v := ValLoss(&Status{Loss: 2, ValLoss: 1, Validated: true}); v != 1 {
+		t.Fatalf("ValLoss expect %v but got %v", 1.0, v)
+	}
+
+	x, tt := toyData()
+	m := &scripted{w: nd.Zeros(nd.NewShape(1)), losses: []float64{2, 3, 1, 4}}
+	e := NewEarlyStopping(m, 2)
+	e.Monitor = ValAccracy
+	e.Maximize = true
+	history, err := NewTrainer(m, &sliceLoader{x: x, t: tt, size: 6}, 4).Add(e).Run()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a := history[1].Accracy; a != -3 {
+		t.Fatalf("expect training accuracy -3 but got %v", a)
+	}
+	if best, epoch := e.Best(); best != -1 || epoch != 2 {
+		t.Fatalf("expect best -1 at epoch 2 but got %v at %d", best, epoch)
+	}
+}
@@ -0,0 +1,29 @@
+package train
+
+import (
+	"fmt"
+
+	"github.com/ajiyoshi/gocnn"
+	"github.com/ajiyoshi/gocnn/dataset"
+)
+
+// ImageBatch reads the examples at of d, of shape (channels, rows, cols),
+// as an image of len(at) samples, and their labels.
+func ImageBatch(d dataset.Dataset, at []int) (gocnn.Image, []int, error) {
+	s := d.Shape()
+	if len(s) != 3 {
+		panic(fmt.Sprintf("expect a shape of (channels, rows, cols) but got %v", s))
+	}
+	r := dataset.NewReader(d)
+	size := dataset.Size(d)
+	data := make([]float64, len(at)*size)
+	labels := make([]int, len(at))
+	for i, n := range at {
+		label, err := r.Read(n, data[i*size:(i+1)*size])
+		if err != nil {
+			return nil, nil, err
+		}
+		labels[i] = label
+	}
+	return gocnn.NewImages(gocnn.NewShape(len(at), s[0], s[1], s[2]), data), labels, nil
+}
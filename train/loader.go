@@ -0,0 +1,92 @@
+package train
+
+import (
+	mat "github.com/gonum/matrix/mat64"
+
+	"github.com/ajiyoshi/gocnn/dataset"
+	"github.com/ajiyoshi/gocnn/mnist"
+)
+
+// Loader loads the batches of an epoch one after another. The matrices
+// returned by Next may be reused by the following call. Err tells whether
+// the epoch ended early because of an error, and is cleared by Reset.
+type Loader interface {
+	Reset(epoch int)
+	Next() (x, t mat.Matrix, ok bool)
+	Err() error
+}
+
+// DatasetLoader loads the examples of Dataset in the batches given by
+// Sampler. A read error ends the epoch, and is kept for Err.
+type DatasetLoader struct {
+	Dataset dataset.Dataset
+	Sampler *dataset.Sampler
+
+	reader dataset.Reader
+	buf    *mnist.TrainBuffer
+	err    error
+}
+
+var _ Loader = (*DatasetLoader)(nil)
+
+func NewDatasetLoader(d dataset.Dataset, s *dataset.Sampler) *DatasetLoader {
+	return &DatasetLoader{Dataset: d, Sampler: s, reader: dataset.NewReader(d)}
+}
+
+func (l *DatasetLoader) Reset(epoch int) {
+	l.err = nil
+	l.Sampler.Reset(epoch)
+}
+
+func (l *DatasetLoader) Next() (x, t mat.Matrix, ok bool) {
+	if l.err != nil {
+		return nil, nil, false
+	}
+	at, ok := l.Sampler.Next()
+	if !ok {
+		return nil, nil, false
+	}
+	if l.buf == nil || l.buf.Rows() != len(at) {
+		l.buf = mnist.NewDatasetBuffer(len(at), l.Dataset)
+	}
+	if l.err = l.buf.Read(l.reader, at); l.err != nil {
+		return nil, nil, false
+	}
+	x, t = l.buf.Bake()
+	return x, t, true
+}
+
+func (l *DatasetLoader) Err() error {
+	return l.err
+}
+
+// TableLoader loads the rows of Table in the batches given by Sampler, with
+// the one-hot labels of a dataset.Table or the targets of a
+// dataset.RegressionTable.
+type TableLoader struct {
+	Table   dataset.Batcher
+	Sampler *dataset.Sampler
+}
+
+var _ Loader = (*TableLoader)(nil)
+
+func NewTableLoader(t dataset.Batcher, s *dataset.Sampler) *TableLoader {
+	return &TableLoader{Table: t, Sampler: s}
+}
+
+func (l *TableLoader) Reset(epoch int) {
+	l.Sampler.Reset(epoch)
+}
+
+func (l *TableLoader) Next() (x, t mat.Matrix, ok bool) {
+	at, ok := l.Sampler.Next()
+	if !ok {
+		return nil, nil, false
+	}
+	x, t = l.Table.Batch(at)
+	return x, t, true
+}
+
+func (l *TableLoader) Err() error {
+	return nil
+}
@@ -0,0 +1,56 @@
+/*
+Package train runs the training loop shared by the commands: epochs of
+batches from a Loader, evaluation on a held-out set and callbacks.
+*/
+package train
+
+import (
+	mat "github.com/gonum/matrix/mat64"
+
+	"github.com/ajiyoshi/gocnn"
+	"github.com/ajiyoshi/gocnn/batch"
+)
+
+// Model is a network learning from a batch of inputs x and one-hot labels t
+// with one example per row.
+type Model interface {
+	Train(x, t mat.Matrix) float64
+	Loss(x, t mat.Matrix) float64
+	Accracy(x, t mat.Matrix) float64
+}
+
+var (
+	_ Model = (*batch.NeuralNet)(nil)
+	_ Model = (*CNN)(nil)
+)
+
+// CNN is a Model of a SimpleCNN, reshaping each row of x to an image of
+// Ch x Row x Col.
+type CNN struct {
+	*gocnn.SimpleCNN
+	Ch  int
+	Row int
+	Col int
+}
+
+func NewCNN(cnn *gocnn.SimpleCNN, ch, row, col int) *CNN {
+	return &CNN{SimpleCNN: cnn, Ch: ch, Row: row, Col: col}
+}
+
+func (c *CNN) image(x mat.Matrix) gocnn.Image {
+	r, _ := x.Dims()
+	return gocnn.NewReshaped(gocnn.NewShape(r, c.Ch, c.Row, c.Col), x)
+}
+
+func (c *CNN) Train(x, t mat.Matrix) float64 {
+	return c.SimpleCNN.Train(c.image(x), t)
+}
+func (c *CNN) Loss(x, t mat.Matrix) float64 {
+	return c.SimpleCNN.Loss(c.image(x), t)
+}
+func (c *CNN) Accracy(x, t mat.Matrix) float64 {
+	return c.SimpleCNN.Accracy(c.image(x), t)
+}
+func (c *CNN) Predict(x mat.Matrix) mat.Matrix {
+	return c.SimpleCNN.Predict(c.image(x))
+}
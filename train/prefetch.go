@@ -0,0 +1,175 @@
+package train
+
+import (
+	"sync"
+
+	mat "github.com/gonum/matrix/mat64"
+
+	"github.com/ajiyoshi/gocnn/dataset"
+	"github.com/ajiyoshi/gocnn/mnist"
+)
+
+// Prefetcher is a Loader reading the next Depth batches of Dataset in
+// Workers goroutines while the current batch trains. Batches come in the
+// order of Sampler whatever the number of workers, and their buffers are
+// reused once the following batch is taken. Close stops the workers.
+type Prefetcher struct {
+	Dataset dataset.Dataset
+	Sampler *dataset.Sampler
+	Workers int
+	Depth   int
+
+	reader  dataset.Reader
+	pool    chan *mnist.TrainBuffer
+	jobs    chan *prefetch
+	workers sync.WaitGroup
+
+	quit    chan struct{}
+	pending chan *prefetch
+	current *mnist.TrainBuffer
+	err     error
+}
+
+type prefetch struct {
+	at   []int
+	buf  *mnist.TrainBuffer
+	err  error
+	done chan struct{}
+}
+
+var _ Loader = (*Prefetcher)(nil)
+
+func NewPrefetcher(d dataset.Dataset, s *dataset.Sampler, workers, depth int) *Prefetcher {
+	if workers <= 0 || depth <= 0 {
+		panic("workers and depth should be positive")
+	}
+	l := &Prefetcher{
+		Dataset: d,
+		Sampler: s,
+		Workers: workers,
+		Depth:   depth,
+		reader:  dataset.NewReader(d),
+		pool:    make(chan *mnist.TrainBuffer, depth+1),
+		jobs:    make(chan *prefetch),
+	}
+	for i := 0; i < depth+1; i++ {
+		l.pool <- mnist.NewDatasetBuffer(s.BatchSize, d)
+	}
+	l.workers.Add(workers)
+	for i := 0; i < workers; i++ {
+		go l.work()
+	}
+	return l
+}
+
+func (l *Prefetcher) work() {
+	defer l.workers.Done()
+	for p := range l.jobs {
+		p.err = p.buf.Read(l.reader, p.at)
+		close(p.done)
+	}
+}
+
+// Reset stops reading the current epoch and starts reading the batches of
+// epoch.
+func (l *Prefetcher) Reset(epoch int) {
+	l.stop()
+	l.err = nil
+	l.Sampler.Reset(epoch)
+	l.quit = make(chan struct{})
+	l.pending = make(chan *prefetch, l.Depth)
+	go l.feed(l.quit, l.pending)
+}
+
+// feed queues the batches of the epoch in order until the sampler runs out
+// or quit is closed.
+func (l *Prefetcher) feed(quit chan struct{}, pending chan *prefetch) {
+	defer close(pending)
+	for {
+		select {
+		case <-quit:
+			return
+		default:
+		}
+		at, ok := l.Sampler.Next()
+		if !ok {
+			return
+		}
+		var buf *mnist.TrainBuffer
+		select {
+		case buf = <-l.pool:
+		case <-quit:
+			return
+		}
+		p := &prefetch{at: append([]int(nil), at...), buf: buf, done: make(chan struct{})}
+		select {
+		case pending <- p:
+		case <-quit:
+			l.pool <- buf
+			return
+		}
+		l.jobs <- p
+	}
+}
+
+// stop ends the current epoch and takes back its buffers.
+func (l *Prefetcher) stop() {
+	if l.quit == nil {
+		return
+	}
+	close(l.quit)
+	for p := range l.pending {
+		<-p.done
+		l.pool <- p.buf
+	}
+	l.release()
+	l.quit = nil
+}
+
+func (l *Prefetcher) release() {
+	if l.current != nil {
+		l.pool <- l.current
+		l.current = nil
+	}
+}
+
+func (l *Prefetcher) Next() (x, t mat.Matrix, ok bool) {
+	l.release()
+	if l.pending == nil || l.err != nil {
+		return nil, nil, false
+	}
+	p, ok := <-l.pending
+	if !ok {
+		return nil, nil, false
+	}
+	<-p.done
+	if p.err != nil {
+		l.err = p.err
+		l.pool <- p.buf
+		return nil, nil, false
+	}
+	l.current = p.buf
+	x, t = p.buf.Bake()
+	if n := len(p.at); n < p.buf.Rows() {
+		x = rowsOf(x, n)
+		t = rowsOf(t, n)
+	}
+	return x, t, true
+}
+
+// Err is the error which ended the epoch early, if any.
+func (l *Prefetcher) Err() error {
+	return l.err
+}
+
+// Close stops the workers. The Prefetcher cannot be used afterwards.
+func (l *Prefetcher) Close() {
+	l.stop()
+	close(l.jobs)
+	l.workers.Wait()
+}
+
+func rowsOf(m mat.Matrix, n int) mat.Matrix {
+	_, c := m.Dims()
+	return m.(*mat.Dense).View(0, 0, n, c)
+}
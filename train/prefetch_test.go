@@ -0,0 +1,139 @@
+package train
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	mat "github.com/gonum/matrix/mat64"
+
+	"github.com/ajiyoshi/gocnn/batch"
+	"github.com/ajiyoshi/gocnn/dataset"
+	"github.com/ajiyoshi/gocnn/optimizer"
+)
+
+func memoryDataset(n int) *dataset.Memory {
+	features := make([][]float64, n)
+	labels := make([]int, n)
+	for i := range features {
+		features[i] = []float64{float64(i), float64(-i)}
+		labels[i] = i % 3
+	}
+	return dataset.NewMemory(features, labels)
+}
+
+// epochRows lists the first feature of every example of an epoch of l.
+func epochRows(l Loader, epoch int) []float64 {
+	var ret []float64
+	l.Reset(epoch)
+	for x, _, ok := l.Next(); ok; x, _, ok = l.Next() {
+		r, _ := x.Dims()
+		for i := 0; i < r; i++ {
+			ret = append(ret, x.At(i, 0))
+		}
+	}
+	return ret
+}
+
+func TestPrefetcher(t *testing.T) {
+	d := memoryDataset(23)
+	cases := []struct {
+		workers int
+		depth   int
+	}{
+		{1, 1}, {4, 2}, {3, 8},
+	}
+	for _, c := range cases {
+		expect := NewDatasetLoader(d, dataset.NewShuffledSampler(d.Len(), 5, 9))
+		l := NewPrefetcher(d, dataset.NewShuffledSampler(d.Len(), 5, 9), c.workers, c.depth)
+		for epoch := 0; epoch < 3; epoch++ {
+			e, a := epochRows(expect, epoch), epochRows(l, epoch)
+			if fmt.Sprint(e) != fmt.Sprint(a) {
+				t.Fatalf("(%d, %d) epoch %d expect %v but got %v", c.workers, c.depth, epoch, e, a)
+			}
+		}
+		l.Close()
+	}
+}
+
+func TestPrefetcherLabels(t *testing.T) {
+	d := memoryDataset(7)
+	l := NewPrefetcher(d, dataset.NewSampler(d.Len(), 3), 2, 2)
+	defer l.Close()
+	l.Reset(0)
+	for x, tt, ok := l.Next(); ok; x, tt, ok = l.Next() {
+		r, _ := x.Dims()
+		if tr, _ := tt.Dims(); tr != r {
+			t.Fatalf("expect %d rows of labels but got %d", r, tr)
+		}
+		for i := 0; i < r; i++ {
+			label := int(x.At(i, 0)) % 3
+			if tt.At(i, label) != 1 {
+				t.Fatalf("row %v expect label %d but got %v", x.At(i, 0), label, mat.Row(nil, i, tt))
+			}
+		}
+	}
+}
+
+func TestPrefetcherReset(t *testing.T) {
+	d := memoryDataset(50)
+	l := NewPrefetcher(d, dataset.NewSampler(d.Len(), 2), 3, 4)
+	defer l.Close()
+
+	// leave an epoch after a batch and start another one
+	l.Reset(0)
+	l.Next()
+	rows := epochRows(l, 1)
+	if len(rows) != 50 || rows[0] != 0 || rows[49] != 49 {
+		t.Fatalf("expect the whole epoch but got %v", rows)
+	}
+}
+
+type failing struct {
+	*dataset.Memory
+	at int
+}
+
+func (f *failing) Read(i int, x []float64) (int, error) {
+	if i == f.at {
+		return 0, errors.New("broken")
+	}
+	return f.Memory.Read(i, x)
+}
+
+func TestPrefetcherError(t *testing.T) {
+	d := &failing{Memory: memoryDataset(10), at: 5}
+	l := NewPrefetcher(d, dataset.NewSampler(d.Len(), 2), 2, 2)
+	defer l.Close()
+	for epoch := 0; epoch < 2; epoch++ {
+		if rows := epochRows(l, epoch); len(rows) != 4 {
+			t.Fatalf("epoch %d expect 4 rows before the error but got %v", epoch, rows)
+		}
+		if l.Err() == nil {
+			t.Fatalf("epoch %d expect an error", epoch)
+		}
+	}
+}
+
+func TestRunError(t *testing.T) {
+	d := &failing{Memory: memoryDataset(10), at: 5}
+	l := NewPrefetcher(d, dataset.NewSampler(d.Len(), 2), 2, 2)
+	defer l.Close()
+	nn := batch.NewSequential(2, optimizer.NewSGDFactory(0.1)).Add(batch.Affine(3)).Build()
+
+	ends := 0
+	history, err := NewTrainer(nn, l, 3).Add(&Hooks{OnEpochEnd: func(*Status) { ends++ }}).Run()
+	if err == nil {
+		t.Fatal("expect the error of the dataset")
+	}
+	if len(history) != 0 || ends != 0 {
+		t.Fatalf("expect no epoch to end but got %d, %d", len(history), ends)
+	}
+
+	_, err = NewTrainer(nn, &sliceLoader{x: mat.NewDense(1, 2, nil), t: mat.NewDense(1, 3, []float64{1, 0, 0}), size: 1}, 1).
+		Validate(l).
+		Run()
+	if err == nil {
+		t.Fatal("expect the error of the validation set")
+	}
+}
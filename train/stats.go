@@ -0,0 +1,31 @@
+package train
+
+import (
+	"github.com/ajiyoshi/gocnn/batch"
+)
+
+// Fit accumulates the statistics of the inputs of the first epoch of l, to
+// fit a batch.Normalize on the training set before training. cov is passed
+// to batch.NewStats. It panics if l is empty, and fails with the error of l.
+func Fit(l Loader, cov bool) (*batch.Stats, error) {
+	l.Reset(0)
+	var s *batch.Stats
+	for {
+		x, _, ok := l.Next()
+		if !ok {
+			break
+		}
+		if s == nil {
+			_, c := x.Dims()
+			s = batch.NewStats(c, cov)
+		}
+		s.Add(x)
+	}
+	if err := l.Err(); err != nil {
+		return nil, err
+	}
+	if s == nil {
+		panic("loader should give at least one batch")
+	}
+	return s, nil
+}
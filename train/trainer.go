@@ -0,0 +1,193 @@
+package train
+
+import (
+	"fmt"
+	"io"
+	"math"
+
+	mat "github.com/gonum/matrix/mat64"
+
+	"github.com/ajiyoshi/gocnn/optimizer"
+)
+
+// Status is the progress of a Trainer given to the callbacks.
+// At the end of a batch Loss is the loss of that batch; at the end of an
+// epoch it is the mean loss of the epoch and ValLoss and ValAccracy are
+// set if Validated, that is if the Trainer has a validation set. Otherwise
+// Accracy is the mean accuracy of the batches of the epoch. The accuracies
+// are NaN for a regression, as Evaluate returns.
+// A callback sets Stop to end the training.
+type Status struct {
+	Epoch      int
+	Batch      int
+	Step       int
+	Loss       float64
+	Accracy    float64
+	ValLoss    float64
+	ValAccracy float64
+	Validated  bool
+	Stop       bool
+}
+
+// Callback is called at the end of each batch and each epoch, and once
+// with the last status when the training ends.
+type Callback interface {
+	BatchEnd(s *Status)
+	EpochEnd(s *Status)
+	TrainEnd(s *Status)
+}
+
+// Hooks is a Callback of functions, which may be nil.
+type Hooks struct {
+	OnBatchEnd func(s *Status)
+	OnEpochEnd func(s *Status)
+	OnTrainEnd func(s *Status)
+}
+
+func (h *Hooks) BatchEnd(s *Status) {
+	if h.OnBatchEnd != nil {
+		h.OnBatchEnd(s)
+	}
+}
+func (h *Hooks) EpochEnd(s *Status) {
+	if h.OnEpochEnd != nil {
+		h.OnEpochEnd(s)
+	}
+}
+func (h *Hooks) TrainEnd(s *Status) {
+	if h.OnTrainEnd != nil {
+		h.OnTrainEnd(s)
+	}
+}
+
+// Logger writes the loss every Every steps, or only at the end of each epoch
+// if Every is 0.
+type Logger struct {
+	W     io.Writer
+	Every int
+}
+
+func (l *Logger) BatchEnd(s *Status) {
+	if l.Every > 0 && s.Step%l.Every == 0 {
+		fmt.Fprintf(l.W, "epoch %d step %d: loss %f\n", s.Epoch, s.Step, s.Loss)
+	}
+}
+func (l *Logger) EpochEnd(s *Status) {
+	if math.IsNaN(s.ValAccracy) {
+		fmt.Fprintf(l.W, "epoch %d: loss %f, val loss %f\n", s.Epoch, s.Loss, s.ValLoss)
+		return
+	}
+	fmt.Fprintf(l.W, "epoch %d: loss %f, val loss %f, val acc %f\n", s.Epoch, s.Loss, s.ValLoss, s.ValAccracy)
+}
+func (l *Logger) TrainEnd(s *Status) {}
+
+// StepScheduler steps s at the end of every epoch.
+func StepScheduler(s *optimizer.Scheduler) Callback {
+	return &Hooks{OnEpochEnd: func(*Status) {
+		s.Step()
+	}}
+}
+
+// Trainer trains Model for Epochs epochs over Train, evaluating it on Val
+// at the end of each epoch unless Val is nil.
+type Trainer struct {
+	Model     Model
+	Train     Loader
+	Val       Loader
+	Epochs    int
+	Callbacks []Callback
+}
+
+func NewTrainer(m Model, train Loader, epochs int) *Trainer {
+	return &Trainer{Model: m, Train: train, Epochs: epochs}
+}
+
+func (tr *Trainer) Validate(val Loader) *Trainer {
+	tr.Val = val
+	return tr
+}
+
+func (tr *Trainer) Add(cb ...Callback) *Trainer {
+	tr.Callbacks = append(tr.Callbacks, cb...)
+	return tr
+}
+
+// Run trains the model and returns the status at the end of each epoch. It
+// stops at the first error of a loader, returning the epochs done so far.
+func (tr *Trainer) Run() ([]Status, error) {
+	var history []Status
+	s := &Status{Validated: tr.Val != nil}
+	for epoch := 0; epoch < tr.Epochs && !s.Stop; epoch++ {
+		s.Epoch = epoch
+		s.Batch = 0
+		sum, acc, n := 0.0, 0.0, 0
+		tr.Train.Reset(epoch)
+		for !s.Stop {
+			x, t, ok := tr.Train.Next()
+			if !ok {
+				break
+			}
+			s.Loss = tr.Model.Train(x, t)
+			sum += s.Loss
+			if tr.Val == nil {
+				acc += accracy(tr.Model, x, t)
+			}
+			n++
+			for _, cb := range tr.Callbacks {
+				cb.BatchEnd(s)
+			}
+			s.Batch++
+			s.Step++
+		}
+		if err := tr.Train.Err(); err != nil {
+			return history, err
+		}
+		if n > 0 {
+			s.Loss = sum / float64(n)
+			if tr.Val == nil {
+				s.Accracy = acc / float64(n)
+			}
+		}
+		if tr.Val != nil {
+			s.ValLoss, s.ValAccracy = Evaluate(tr.Model, tr.Val, epoch)
+			if err := tr.Val.Err(); err != nil {
+				return history, err
+			}
+		}
+		for _, cb := range tr.Callbacks {
+			cb.EpochEnd(s)
+		}
+		history = append(history, *s)
+	}
+	for _, cb := range tr.Callbacks {
+		cb.TrainEnd(s)
+	}
+	return history, nil
+}
+
+// Evaluate returns the loss and the accuracy of m over one epoch of l,
+// weighting each batch by its size. The accuracy is NaN for targets of a
+// single column, which are those of a regression. Check l.Err afterwards.
+func Evaluate(m Model, l Loader, epoch int) (loss, acc float64) {
+	n := 0
+	l.Reset(epoch)
+	for x, t, ok := l.Next(); ok; x, t, ok = l.Next() {
+		r, _ := x.Dims()
+		loss += m.Loss(x, t) * float64(r)
+		acc += accracy(m, x, t) * float64(r)
+		n += r
+	}
+	if n == 0 {
+		return 0, 0
+	}
+	return loss / float64(n), acc / float64(n)
+}
+
+// accracy is the accuracy of m on a batch, or NaN for the targets of a
+// regression.
+func accracy(m Model, x, t mat.Matrix) float64 {
+	if _, c := t.Dims(); c == 1 {
+		return math.NaN()
+	}
+	return m.Accracy(x, t)
+}
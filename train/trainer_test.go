@@ -0,0 +1,298 @@
+package train
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"strings"
+	"testing"
+
+	mat "github.com/gonum/matrix/mat64"
+
+	"github.com/ajiyoshi/gocnn"
+	"github.com/ajiyoshi/gocnn/batch"
+	"github.com/ajiyoshi/gocnn/dataset"
+	"github.com/ajiyoshi/gocnn/optimizer"
+)
+
+// sliceLoader gives the rows of x and t by batches of size.
+type sliceLoader struct {
+	x, t  *mat.Dense
+	size  int
+	pos   int
+	epoch int
+}
+
+func (l *sliceLoader) Reset(epoch int) {
+	l.pos = 0
+	l.epoch = epoch
+}
+func (l *sliceLoader) Next() (x, t mat.Matrix, ok bool) {
+	r, _ := l.x.Dims()
+	if l.pos >= r {
+		return nil, nil, false
+	}
+	n := l.size
+	if l.pos+n > r {
+		n = r - l.pos
+	}
+	_, xc := l.x.Dims()
+	_, tc := l.t.Dims()
+	x = l.x.View(l.pos, 0, n, xc)
+	t = l.t.View(l.pos, 0, n, tc)
+	l.pos += n
+	return x, t, true
+}
+func (l *sliceLoader) Err() error { return nil }
+
+func toyData() (x, t *mat.Dense) {
+	x = mat.NewDense(6, 4, []float64{
+		1, 0, 0, 0,
+		0, 1, 0, 0,
+		0, 0, 1, 0,
+		1, 0, 0, 1,
+		0, 1, 0, 1,
+		0, 0, 1, 1,
+	})
+	t = mat.NewDense(6, 3, []float64{
+		1, 0, 0,
+		0, 1, 0,
+		0, 0, 1,
+		1, 0, 0,
+		0, 1, 0,
+		0, 0, 1,
+	})
+	return x, t
+}
+
+func TestTrainer(t *testing.T) {
+	x, tt := toyData()
+	nn := batch.NewSequential(4, optimizer.NewSGDFactory(0.5)).
+		InitStd(0.1).
+		Add(batch.Affine(8)).Add(batch.ReLU()).Add(batch.Affine(3)).
+		Build()
+
+	batches := 0
+	var w bytes.Buffer
+	tr := NewTrainer(nn, &sliceLoader{x: x, t: tt, size: 4}, 30).
+		Validate(&sliceLoader{x: x, t: tt, size: 6}).
+		Add(&Hooks{OnBatchEnd: func(s *Status) {
+			batches++
+		}}).
+		Add(&Logger{W: &w})
+
+	history, err := tr.Run()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(history) != 30 {
+		t.Fatalf("expect 30 epochs but got %d", len(history))
+	}
+	if batches != 60 {
+		t.Fatalf("expect 60 batches but got %d", batches)
+	}
+	last := history[len(history)-1]
+	if last.Step != 60 || last.Epoch != 29 {
+		t.Fatalf("expect step 60 of epoch 29 but got %d of %d", last.Step, last.Epoch)
+	}
+	if history[0].ValLoss <= last.ValLoss {
+		t.Fatalf("val loss should decrease but got %v -> %v", history[0].ValLoss, last.ValLoss)
+	}
+	if last.ValAccracy != 1 {
+		t.Fatalf("expect accuracy 1 but got %v", last.ValAccracy)
+	}
+	if n := strings.Count(w.String(), "\n"); n != 30 {
+		t.Fatalf("expect 30 lines of log but got %d", n)
+	}
+}
+
+func TestTrainerStop(t *testing.T) {
+	x, tt := toyData()
+	nn := batch.NewSequential(4, optimizer.NewSGDFactory(0.1)).Add(batch.Affine(3)).Build()
+
+	cases := []struct {
+		title  string
+		hooks  *Hooks
+		epochs int
+		steps  int
+	}{
+		{
+			title:  "stop at a batch",
+			hooks:  &Hooks{OnBatchEnd: func(s *Status) { s.Stop = s.Step == 4 }},
+			epochs: 2,
+			steps:  5,
+		},
+		{
+			title:  "stop at an epoch",
+			hooks:  &Hooks{OnEpochEnd: func(s *Status) { s.Stop = s.Epoch == 1 }},
+			epochs: 2,
+			steps:  6,
+		},
+	}
+	for _, c := range cases {
+		history, err := NewTrainer(nn, &sliceLoader{x: x, t: tt, size: 2}, 10).Add(c.hooks).Run()
+		if err != nil {
+			t.Fatalf("%s %v", c.title, err)
+		}
+		if len(history) != c.epochs {
+			t.Fatalf("%s expect %d epochs but got %d", c.title, c.epochs, len(history))
+		}
+		if s := history[len(history)-1].Step; s != c.steps {
+			t.Fatalf("%s expect %d steps but got %d", c.title, c.steps, s)
+		}
+	}
+}
+
+func TestStepScheduler(t *testing.T) {
+	x, tt := toyData()
+	s := optimizer.NewScheduler(&optimizer.ExponentialDecay{Gamma: 0.5})
+	nn := batch.NewSequential(4, s.Factory(optimizer.NewSGDFactory(0.1))).Add(batch.Affine(3)).Build()
+
+	NewTrainer(nn, &sliceLoader{x: x, t: tt, size: 6}, 2).Add(StepScheduler(s)).Run()
+	if lr := s.LearningRate(); lr != 0.025 {
+		t.Fatalf("expect 0.025 but got %v", lr)
+	}
+}
+
+func TestCNN(t *testing.T) {
+	x, tt := toyData()
+	cnn := gocnn.NewCNNBuilder(gocnn.NewShape(6, 1, 2, 2), optimizer.NewSGDFactory(0.5)).
+		InitStd(0.1).
+		Conv(4, 2, 1, 1).ReLU().
+		Dense(batch.Affine(3)).
+		Build()
+	m := NewCNN(cnn, 1, 2, 2)
+
+	before, _ := Evaluate(m, &sliceLoader{x: x, t: tt, size: 6}, 0)
+	NewTrainer(m, &sliceLoader{x: x, t: tt, size: 6}, 20).Run()
+	after, _ := Evaluate(m, &sliceLoader{x: x, t: tt, size: 6}, 0)
+	if after >= before {
+		t.Fatalf("loss should decrease but got %v -> %v", before, after)
+	}
+}
+
+func TestDatasetLoader(t *testing.T) {
+	d := dataset.NewMemory([][]float64{{0, 1}, {2, 3}, {4, 5}}, []int{0, 1, 2})
+	l := NewDatasetLoader(d, dataset.NewSampler(d.Len(), 2))
+	for epoch := 0; epoch < 2; epoch++ {
+		l.Reset(epoch)
+		var rows []int
+		for x, tt, ok := l.Next(); ok; x, tt, ok = l.Next() {
+			r, _ := x.Dims()
+			rows = append(rows, r)
+			if x.At(0, 0) == 4 && tt.At(0, 2) != 1 {
+				t.Fatalf("expect label 2 but got %v", mat.Formatted(tt))
+			}
+		}
+		if len(rows) != 2 || rows[0] != 2 || rows[1] != 1 {
+			t.Fatalf("expect batches of [2 1] but got %v", rows)
+		}
+	}
+}
+
+func TestDatasetLoaderError(t *testing.T) {
+	d := &failing{Memory: memoryDataset(10), at: 5}
+	l := NewDatasetLoader(d, dataset.NewSampler(d.Len(), 2))
+	for epoch := 0; epoch < 2; epoch++ {
+		if rows := epochRows(l, epoch); len(rows) != 4 {
+			t.Fatalf("epoch %d expect 4 rows before the error but got %v", epoch, rows)
+		}
+		if l.Err() == nil {
+			t.Fatalf("epoch %d expect an error", epoch)
+		}
+	}
+}
+
+func TestAugmented(t *testing.T) {
+	d := memoryDataset(9)
+	d.Dims = []int{1, 1, 2}
+	noise := &gocnn.GaussianNoise{Std: 1}
+	a := NewAugmented(NewDatasetLoader(d, dataset.NewSampler(d.Len(), 4)), noise, 1, 1, 2, 5)
+	b := NewAugmented(NewDatasetLoader(d, dataset.NewSampler(d.Len(), 4)), noise, 1, 1, 2, 5)
+
+	e0, e1 := epochRows(a, 0), epochRows(a, 1)
+	if fmt.Sprint(e0) != fmt.Sprint(epochRows(b, 0)) {
+		t.Fatalf("same seed should give the same batches")
+	}
+	if fmt.Sprint(e0) == fmt.Sprint(e1) {
+		t.Fatalf("each epoch should be augmented differently")
+	}
+	if len(e0) != 9 || e0[0] == 0 {
+		t.Fatalf("expect 9 noisy rows but got %v", e0)
+	}
+}
+
+func TestFit(t *testing.T) {
+	x, tt := toyData()
+	s, err := Fit(&sliceLoader{x: x, t: tt, size: 4}, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.Len() != 6 {
+		t.Fatalf("expect 6 but got %d", s.Len())
+	}
+	expect := mat.NewVector(4, []float64{1.0 / 3, 1.0 / 3, 1.0 / 3, 0.5})
+	if !mat.EqualApprox(s.Mean(), expect, 1e-12) {
+		t.Fatalf("expect %v but got %v", mat.Formatted(expect), mat.Formatted(s.Mean()))
+	}
+}
+
+func TestImageBatch(t *testing.T) {
+	d := &dataset.Memory{
+		Features: [][]float64{
+			{1, 2, 3, 4, 5, 6, 7, 8},
+			{9, 10, 11, 12, 13, 14, 15, 16},
+			{17, 18, 19, 20, 21, 22, 23, 24},
+		},
+		Labels:     []int{0, 2, 1},
+		Dims:       []int{2, 2, 2},
+		NumClasses: 3,
+	}
+	img, labels, err := ImageBatch(d, []int{2, 0})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s := img.Shape(); *s != *gocnn.NewShape(2, 2, 2, 2) {
+		t.Fatalf("expect (2, 2, 2, 2) but got %v", *s)
+	}
+	if img.Get(0, 1, 0, 1) != 22 || img.Get(1, 0, 1, 0) != 3 {
+		t.Fatalf("expect 22 and 3 but got %v", img)
+	}
+	if labels[0] != 1 || labels[1] != 0 {
+		t.Fatalf("expect [1 0] but got %v", labels)
+	}
+}
+
+func TestTableLoader(t *testing.T) {
+	var csv strings.Builder
+	csv.WriteString("x1,x2,y\n")
+	for i := 0; i < 20; i++ {
+		x1, x2 := float64(i%5)/5, float64(i%4)/4
+		fmt.Fprintf(&csv, "%v,%v,%v\n", x1, x2, 2*x1-x2+0.5)
+	}
+	tab, err := dataset.ReadRegressionCSV(strings.NewReader(csv.String()), &dataset.CSVOptions{Target: "y"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	seq := batch.NewSequential(2, optimizer.NewSGDFactory(0.1)).Add(&batch.AffineSpec{Output: 1, Std: 0.1})
+	seq.Loss = func() batch.LastLayer {
+		return batch.NewMeanSquaredLoss()
+	}
+	nn := seq.Build()
+	l := NewTableLoader(tab, dataset.NewShuffledSampler(tab.Len(), 5, 1))
+	var log bytes.Buffer
+	status, err := NewTrainer(nn, l, 200).
+		Validate(NewTableLoader(tab, dataset.NewSampler(tab.Len(), 20))).
+		Add(&Logger{W: &log}).
+		Run()
+	if err != nil {
+		t.Fatal(err)
+	}
+	last := status[len(status)-1]
+	if last.ValLoss > 1e-3 {
+		t.Fatalf("loss should be close to 0 but got %v", last.ValLoss)
+	}
+	if !math.IsNaN(last.ValAccracy) || strings.Contains(log.String(), "val acc") {
+		t.Fatalf("a regression should have no accuracy but got %v", last.ValAccracy)
+	}
+}
@@ -0,0 +1,99 @@
+package gocnn
+
+import (
+	"io"
+)
+
+// Filters are the filters of c as an image of one sample per channel of
+// each filter, or of one RGB sample per filter if the filters have 3
+// channels.
+func Filters(c *Convolution) Image {
+	s := c.Weight.Shape()
+	if s.Ch == 3 {
+		return c.Weight
+	}
+	return splitChannels(c.Weight, 0, s.N)
+}
+
+// WriteFilters writes the filters of c as a PNG grid, with one row per
+// filter and each tile normalised to its own range. scale is the size in
+// pixels of a weight.
+func WriteFilters(w io.Writer, c *Convolution, scale int) error {
+	s := c.Weight.Shape()
+	g := &Grid{Cols: s.Ch, Scale: scale, PerTile: true}
+	if s.Ch == 3 {
+		g.Cols = 0
+	}
+	return g.WritePNG(w, Filters(c))
+}
+
+// FeatureMaps are the outputs of l for the n-th sample of x, one sample per
+// channel. It runs l.Forward on x, so that the next Backword of l refers to
+// x.
+func FeatureMaps(l ImageLayer, x Image, n int) Image {
+	out := l.Forward(x)
+	return splitChannels(out, n, n+1)
+}
+
+// WriteFeatureMaps writes the feature maps of l for the n-th sample of x as
+// a PNG grid. The tiles share the range of the values, so that dead
+// channels are black and saturated ones white.
+func WriteFeatureMaps(w io.Writer, l ImageLayer, x Image, n, scale int) error {
+	g := &Grid{Scale: scale}
+	return g.WritePNG(w, FeatureMaps(l, x, n))
+}
+
+// Activations are the outputs of the image layers of cnn for img, in order.
+func (cnn *SimpleCNN) Activations(img Image) []Image {
+	ret := make([]Image, 0, len(cnn.imageLayers))
+	for _, layer := range cnn.imageLayers {
+		img = layer.Forward(img)
+		ret = append(ret, img)
+	}
+	return ret
+}
+
+// DeadChannels are the channels of img which are 0 for every sample, like
+// the outputs of a ReLU whose filter never fires.
+func DeadChannels(img Image) []int {
+	s := img.Shape()
+	var ret []int
+	for ch := 0; ch < s.Ch; ch++ {
+		if isZeroChannel(img, ch) {
+			ret = append(ret, ch)
+		}
+	}
+	return ret
+}
+
+func isZeroChannel(img Image, ch int) bool {
+	s := img.Shape()
+	for n := 0; n < s.N; n++ {
+		for r := 0; r < s.Row; r++ {
+			for c := 0; c < s.Col; c++ {
+				if img.Get(n, ch, r, c) != 0 {
+					return false
+				}
+			}
+		}
+	}
+	return true
+}
+
+// splitChannels makes every channel of the samples from to to a sample of
+// one channel.
+func splitChannels(img Image, from, to int) Image {
+	s := img.Shape()
+	ret := NewEmptyStrage(NewShape((to-from)*s.Ch, 1, s.Row, s.Col))
+	for n := from; n < to; n++ {
+		for ch := 0; ch < s.Ch; ch++ {
+			i := (n-from)*s.Ch + ch
+			for r := 0; r < s.Row; r++ {
+				for c := 0; c < s.Col; c++ {
+					ret.Set(i, 0, r, c, img.Get(n, ch, r, c))
+				}
+			}
+		}
+	}
+	return ret
+}
@@ -0,0 +1,71 @@
+package gocnn
+
+import (
+	"bytes"
+	"image/png"
+	"testing"
+)
+
+func TestFilters(t *testing.T) {
+	// 2 filters of 2 channels of 1x2
+	c := &Convolution{Weight: NewImages(NewShape(2, 2, 1, 2), []float64{
+		1, 2, 3, 4,
+		5, 6, 7, 8,
+	})}
+	f := Filters(c)
+	if s := f.Shape(); *s != *NewShape(4, 1, 1, 2) {
+		t.Fatalf("expect %v but got %v", NewShape(4, 1, 1, 2), s)
+	}
+	if v := f.Get(2, 0, 0, 1); v != 6 {
+		t.Fatalf("filter 1 channel 0 expect %v but got %v", 6.0, v)
+	}
+
+	var buf bytes.Buffer
+	if err := WriteFilters(&buf, c, 2); err != nil {
+		t.Fatal(err)
+	}
+	out, err := png.Decode(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 2 tiles of 4x2 pixels with 1 pixel around per row
+	if b := out.Bounds(); b.Dx() != 12 || b.Dy() != 8 {
+		t.Fatalf("expect 12x8 but got %dx%d", b.Dx(), b.Dy())
+	}
+
+	rgb := &Convolution{Weight: NewRandomImage(NewShape(4, 3, 2, 2), 1)}
+	if f := Filters(rgb); f != rgb.Weight {
+		t.Fatalf("expect the weight of RGB filters as they are")
+	}
+}
+
+func TestFeatureMaps(t *testing.T) {
+	// 2 samples of 3 channels of 1x2, the channel 1 being negative
+	x := NewImages(NewShape(2, 3, 1, 2), []float64{
+		1, 2, -1, -2, 0, 0,
+		3, 4, -3, -4, 0, 5,
+	})
+	maps := FeatureMaps(&ReLU{}, x, 1)
+	expect := []float64{3, 4, 0, 0, 0, 5}
+	if s := maps.Shape(); *s != *NewShape(3, 1, 1, 2) {
+		t.Fatalf("expect %v but got %v", NewShape(3, 1, 1, 2), s)
+	}
+	for i, v := range expect {
+		if got := maps.Get(i/2, 0, 0, i%2); got != v {
+			t.Fatalf("value %d expect %v but got %v", i, v, got)
+		}
+	}
+
+	dead := DeadChannels((&ReLU{}).Forward(x))
+	if len(dead) != 1 || dead[0] != 1 {
+		t.Fatalf("expect %v but got %v", []int{1}, dead)
+	}
+
+	var buf bytes.Buffer
+	if err := WriteFeatureMaps(&buf, &ReLU{}, x, 0, 1); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := png.Decode(&buf); err != nil {
+		t.Fatal(err)
+	}
+}